@@ -0,0 +1,37 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package bitpeek
+
+// Alphabets are what the a digit of a Ga.dd@ command picks. Alphabet of 32
+// characters makes a char of 5 bits, alphabet of 64 characters makes it of
+// 6 bits. Entries 5 to 9 are for your own alphabets. Set them once, before
+// use. Alphabet of any other length makes Ga.dd@ a PICERR.
+var Alphabets = [10]string{
+	"abcdefghijklmnopqrstuvwxyz234567",                                 // C32s, as G
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ234567",                                 // RFC 4648 Base32
+	"0123456789ABCDEFGHJKMNPQRSTVWXYZ",                                 // Crockford Base32
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/", // Base64
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_", // Base64URL
+}
+
+// chars writes k bits of v as characters of alphabet a, the most
+// significant first. It fills ot leftwards from oi and returns new oi.
+// Room of 13 is needed.
+func chars(ot []byte, oi int, v uint64, k uint, a string) int {
+	w := uint(5)
+	if len(a) == 64 {
+		w = 6
+	}
+	for i := uint(0); i < k; i += w {
+		m := uint64(1)<<w - 1
+		if k-i < w {
+			m = 1<<(k-i) - 1
+		}
+		oi--
+		ot[oi] = a[v&m]
+		v >>= w
+	}
+	return oi
+}
@@ -0,0 +1,51 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"testing"
+)
+
+func TestAlphabets(t *testing.T) {
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := 0; i < 1024; i++ { // xorshift
+		x ^= x << 13
+		x ^= x >> 7
+		x ^= x << 17
+		var b [8]byte
+		for j := range b {
+			b[j] = byte(x >> (56 - 8*j))
+		}
+		// 40 bits make 8 base32 chars, 48 bits make 8 base64 chars
+		if o, e := string(Snap(`G1.40@!24@`, x)), base32.StdEncoding.EncodeToString(b[:5]); o != e {
+			t.Errorf("Base32 %#x: got %s want %s", x, o, e)
+		}
+		if o, e := string(Snap(`G3.48@!16@`, x)), base64.StdEncoding.EncodeToString(b[:6]); o != e {
+			t.Errorf("Base64 %#x: got %s want %s", x, o, e)
+		}
+		if o, e := string(Snap(`G4.48@!16@`, x)), base64.URLEncoding.EncodeToString(b[:6]); o != e {
+			t.Errorf("Base64URL %#x: got %s want %s", x, o, e)
+		}
+		if o, e := string(Snap(`G0.05@`, x)), string(Snap(`G`, x)); o != e {
+			t.Errorf("C32s %#x: got %s want %s", x, o, e)
+		}
+	}
+}
+
+func TestAlphabetCustom(t *testing.T) {
+	defer func(a string) { Alphabets[9] = a }(Alphabets[9])
+	Alphabets[9] = "0123456789bcdefghjkmnpqrstuvwxyz" // geohash
+	if o := string(Snap(`G9.25@`, 0xdfe082)); o != "ezs42" {
+		t.Errorf("geohash: got %s", o)
+	}
+	Alphabets[9] = "too short"
+	if o := string(Snap(`G9.25@`, 0)); o != "ICERR!" {
+		t.Errorf("short alphabet: got %s", o)
+	}
+}
@@ -4,8 +4,8 @@
 
 // Bitpacked data pretty-formatter. Makes bits human readable. Zero dependencies.
 // Every single input bit from 0 to 63 can print a label that show this bit state.
-// Arbitrary group of bits can be printed as decimal, octal or hex numbers and as
-// C32s, Ascii7b or UTF8 characters. Plus as an IPv4 address in dot-notation.
+// Arbitrary group of bits can be printed as decimal, binary, octal or hex numbers
+// and as C32s, Ascii7b or UTF8 characters. Plus as an IPv4 address in dot-notation.
 // Taste it:
 //     var header uint64 = 0xafdfdeadbeef4d0e
 //
@@ -18,13 +18,15 @@
 //     //   Benchmark:   277 ns/op  64 B/op 1 allocs/op (Sprintf: 862 ns/op)
 //     // EscAnalysis: make([]byte, oi) escapes to heap
 //
-// Package has NO dependencies and its parser is under 170 LoC so it is useful
+// Package has NO dependencies and its parser is about 500 LoC so it is useful
 // where standard "fmt" and "log" packages are too heavy to use (ie. IoT, embed
 // and high-throughput environments). Parser allocates heap memory only for
 // its output. Pic (format) string is written in left-to-right order (most
 // significant bit, b63 is on the left) so any shorter uint based type can be
 // simply cast and fed to Snap function. Bitpeek has an accompanying tool
 // (bplint) you ought to use to validate all picstrings in your source file(s).
+// Pics with no blocks and no repeats run in a lean loop of their own, the rest
+// take a slower one that keeps their state.
 //
 //    BITPEEK FORMAT STRING
 //
@@ -52,71 +54,63 @@
 //    H - 4 bits : hex digit 0..F : H for Hex
 //    G - 5 bits : C32s character :              (CRC  spelling code)
 //    A - 7 bits : 7b char/ascii; : A for Ascii  (emits ~ for A < 32)
-//    C - 8 bits : 8b char/utf8;  : C for Char   (emits ~ for C < 32)
+//    C - 8 bits : 8b char/utf8;  : C for Char   (~ for C < 32)
+//                                  Set Escape for other signs than ~,
+//                                  these escape DEL and C1 too.
 //    I -32 bits : IPv4 address   : Pic is IPv4.Address32@
 //    D -dd bits : Decimal number : Pic is D.dd@           01< dd <16.
+//    B -dd bits : Binary digits  : Pic is B_dd@ for 0101_1100. B.dd@ ungrouped.
+//    X -dd bits : Hex number     : Pic is X.dd@, x.dd@ for lowercase digits.
+//    O -dd bits : Octal number   : Pic is O.dd@. X0dd@ x0dd@ O0dd@ zero pad.
+//    Q -dd bits : Fixed point    : Pic is Qff.p.dd@ ff fraction bits, p places.
+//    U -dd bits : Unsigned fixed : Pic is Uff.p.dd@ (Q is two's complement).
+//    Float16@   : IEEE-754 half  : shortest exact, as strconv 'g'. Float32@ too.
+//    G -dd bits : 5 or 6b chars  : Pic is Ga.dd@  a is Alphabets index: 0 C32s,
+//                                  1 Base32, 2 Crockford, 3 Base64, 4 URL.
+//    T -dd bits : RFC 3339 time  : Pic is Tue.dd@  u is unit: s m u n for
+//                                  seconds, ms, µs, ns. e is Epochs index.
+//    P -dd bits : Duration       : Pic is Pu.dd@ for 1h2m3.5s. u as above.
 //    ! -dd bits : SKIP 'dd' bits : Pic is !dd@  (>>dd)    01< dd <63.
 //    @          : dd@ (bitcount) : two digit number of bits to take.
+//    #d         : word switch    : SnapN only. Take next bits from word d.
+//    {n}        : repeat command : H{12} is HHHHHHHHHHHH.      0< n <1000.
+//    {n/gS}     : with separator : B{16/4_} puts _ between groups of 4 bits.
+//
+//    BLOCKS
+//    >[ text ]  : block is shown only if > bit is SET. Pic: 'EXT>[ Id:HH]
+//    <[ text ]  : block is shown only if < bit is UNSET.
+//               Commands in a hidden block still take their bits.
+//    S[v0|v1|…] : switch. Selector S (B E F H G A C or dd@ command)
+//               picks variant by its value. Pic: 'T:'F[ ping:HH| raw:HH]
+//               Variant 0 is the leftmost. Unknown value shows nothing.
+//               All variants take as many bits as the last one does.
 //
 // Picstrings linter is avaliable at https://github.com/ohir/bplint
 //   go get github.com/ohir/bplint
 package bitpeek
 
-// Func Snap takes a string and an uint64 as input data. It returns byteslice
-// filled with printable characters as directed by pic (format) string. Pic
-// string represents b63 on its left and b0 on the right. Parser starts at b0
-// so shorter ints can be simply cast.
-//
-// Notes
-//
-// ➊ Use !dd@ skips if you are interested only in bits on higher positions:
-//     fmt.Printf("%s\n", bitpeek.Snap( // 48 to skip on the left of @.
-//           `'Type:'F 'EXT=.ACK= Id:0xFHH!48@`, 0xafdfdeadbeef4d0e))
-//
-//     Output:
-//     Type:5 ext.ACK Id:0x7DF
-//
-// ➋ UTF8 and ascii control characters (eg. NL) are passed as-is so you
-// can make readable conditional "label-line":
-//    bitpeek.Snap(`'
-//    This line will show only if bit b1 is set>
-//    This line will show only if bit b0 is unset<`,
-//    header)
-//
-// ➌ It is possible to omit opening ' for a label at the start of
-// the pic string:
-//    pic := `' Label<` //
-//    pic :=  ` Label<` // same effect as above
-//
-// ➍ H commands are grouped so \HHHH is a pic for 16b number in spite
-// of escape (backslash) in front of first H. Use 'H'HHH if you really
-// need literal H glued to the front of hex digits.
-//
-// ➎ \n\t escapes are always interpreted - even in a quoted text. There is
-// no way to output literal `\n` or `\t`. Don't try.
-//
-// ➏ Annotate all your strings with bplinter tag in form of special comment
-// put ABOVE the line(s) with the pictring itself:
-//
-//   //bitpeek:tag:skip
-//
-// Optional ":tag" field is used to match with linter's -m option.
-// Picstring tags need not to be unique. Optional ":skip" number tells linter
-// to skip a few (up to 7) next strings.  It helps where the picstring in the
-// source is a part of a longer literal:
-//
-//  //bitpeek:sometag:1
-//  {`Example`, `Type:'F 'EXT=.ACK= Id:0xFHH from IPv4.Address32@:D.16@`},
-//
-//  // :1 skips string `Example`
-//
-func Snap(pic string, from uint64) []byte {
-	pi := len(pic)         // pic index
-	oi := pi               // output index
-	var asis, c byte       // flow control, temp c
-	ot := make([]byte, oi) //
+import (
+	"github.com/ohir/bitpeek/internal/format"
+	"github.com/ohir/bitpeek/internal/parse"
+)
 
-ploop:
+// snap is the Snap parser. It fills ot leftwards from oi and returns both
+// as ot may be reallocated. Room of at least len(pic) must be there on the
+// left of oi.
+func snap(pic string, from uint64, ot []byte, oi int, p *peek) ([]byte, int) {
+	if p == nil {
+		if i, ok := plain(pic, from, ot, oi); ok {
+			return ot, i
+		}
+	}
+	pi := len(pic)     // pic index
+	var nb, n uint     // bits taken: total, by command
+	var asis byte      // flow control
+	var bs [4]blk      // open blocks
+	var bn int         // open blocks count
+	var rj, rn, rg int // repeat: { index, count, group
+	var rd int         // repeat: done so far
+	var rs byte        // repeat: group separator
 	for pi > 0 {
 		pi--
 		w := pic[pi]
@@ -132,6 +126,93 @@ ploop:
 			ot[oi] = w
 			pi-- // skip leading \
 			continue
+		case w-48 < 10 && pi > 0 && pic[pi-1] == '#' && p != nil && p.multi &&
+			(pi < 2 || pic[pi-2] != '\\'): // #d word switch, SnapN only
+			pi--
+			lo, d := 0, 0 // start of the variant we are in, its word
+			if bn > 0 && bs[bn-1].v < pi {
+				lo, d = bs[bn-1].v+1, bs[bn-1].cur
+			}
+			from = p.to(word(pic[lo:pi], d), from)
+			continue
+		case w == '}' && asis == 0 && rn == 0: // X{n/gS} repeat
+			if rj, rn, rg, rs = parse.Repeat(pic[:pi]); rn > 0 {
+				rd, pi = 0, rj
+				continue
+			}
+		case w == ']' && asis != 1 && bn < len(bs): // block end
+			if j, v, sw := parse.Block(pic[:pi]); j > 0 {
+				bs[bn] = blk{j: j, v: v, k: pi, e: len(ot) - oi, f: from, nb: nb, sw: sw, s: -1}
+				if p != nil {
+					p.open(&bs[bn])
+				}
+				if p != nil && p.multi {
+					b := &bs[bn]
+					b.cur, b.ws = p.cur, p.words
+					from = p.to(word(pic[b.v+1:pi], b.cur), from)
+				}
+				bn++
+				asis = 0
+				continue
+			}
+		case bn > 0 && pi == bs[bn-1].v: // variant or block start
+			b := &bs[bn-1]
+			if p != nil && p.multi { // back to the word of the block
+				from = p.to(b.cur, from)
+			}
+			asis = 0
+			show := true
+			switch {
+			case b.sw == 0: // >[ <[ conditional
+				show = (pic[pi-1] == '>') == (from&1 == 1)
+			case b.s < 0: // last variant done. Now from is at selector
+				b.w, b.fw = nb-b.nb, from
+				if p != nil && p.chk {
+					p.variants(pic, b)
+				}
+				if p != nil && p.size {
+					p.selector(pic, b, nb)
+				}
+				if p != nil && p.pack && p.err == nil {
+					p.err = &PicError{b.j, "Pack can not fill a switch block"}
+				}
+				sel := from
+				if b.sw < 64 {
+					sel &= 1<<b.sw - 1
+				}
+				l, r := parse.Variant(pic[:b.k], b.j, sel)
+				if r == b.k { // it was the one
+					break
+				}
+				if r < 0 { // no such variant
+					show = false
+					break
+				}
+				oi = len(ot) - b.e
+				b.s, b.v, pi = int(sel), l, r
+				from, nb = b.f, b.nb
+				if p != nil {
+					p.redo(b)
+				}
+				if p != nil && p.multi {
+					p.cur, p.words = b.cur, b.ws
+					from = p.to(word(pic[l+1:r], b.cur), from)
+				}
+				continue
+			default: // selected variant done
+				from, nb = b.fw, b.nb+b.w
+			}
+			if !show {
+				oi = len(ot) - b.e
+				if p != nil {
+					p.hide(b, nb)
+				}
+			}
+			pi = b.j
+			bn--
+			continue
+		}
+		switch {
 		case asis == 0: // goto control
 		case w == '\'':
 			asis = 0
@@ -154,10 +235,102 @@ ploop:
 		case asis == 4: // skip label
 			continue
 		}
+		if w == '\'' { // 1: quoted
+			asis = 1
+			continue
+		}
+		if p != nil && w != 0 { // NUL shows nothing, for cut it is the pic end
+			ot, oi = p.cut(ot, oi, pi, nb, w)
+			p.nb = nb
+		}
+		var ok bool
+		if pi, n, asis, ot, oi, ok = cmd(pic, pi, from, asis, ot, oi, p); !ok {
+			break
+		}
+		if n != 0 {
+			if p != nil {
+				p.cs = pi
+				if p.size {
+					p.spans = append(p.spans, span{pi, nb, n})
+				}
+			}
+			from >>= n
+			nb += n
+		}
+		if rn > 0 && pi < rj { // repeated command done
+			if rd++; rd == rn {
+				rn = 0
+				continue
+			}
+			ot, oi = grow(ot, oi, rj+1)
+			if rg > 0 && rd%rg == 0 {
+				oi--
+				ot[oi] = rs
+			}
+			pi = rj
+		}
+	}
+	if p != nil {
+		p.nb = nb
+		ot, oi = p.cut(ot, oi, 0, nb, 0)
+	}
+	return ot, oi
+}
+
+// plain is snap for pics with no blocks and no repeats, so Snap of usual
+// pics pays nothing for them. It runs the usual commands in place, as Snap
+// did before blocks came, and it never grows ot. It returns false as soon as
+// it meets a block or repeat end, a char to escape or an @ command it does
+// not know, then snap starts over from oi.
+func plain(pic string, from uint64, ot []byte, oi int) (int, bool) {
+	pi := len(pic) // pic index
+	var asis byte  // flow control
+	for pi > 0 {
+		pi--
+		w := pic[pi]
+		switch { // labels and escapes
+		case pi > 0 && pic[pi-1] == '\\':
+			switch w {
+			case 'n':
+				w = '\n'
+			case 't':
+				w = '\t'
+			}
+			oi--
+			ot[oi] = w
+			pi-- // skip leading \
+			continue
+		case asis == 0: // goto control
+		case w == '\'':
+			asis = 0
+			continue
+		case asis == 1: // '' emit quoted
+			oi--
+			ot[oi] = w
+			continue
+		case w == ']': // block end
+			return oi, false
+		case w|3 == 63: // next label ahead
+			asis = 0
+		case asis == 2: // lowercase label
+			if w > 63 && w < 91 {
+				w |= 0x20
+			}
+			fallthrough
+		case asis == 3: // emit label.
+			oi--
+			ot[oi] = w
+			continue
+		case asis == 4: // skip label
+			continue
+		}
+		var c byte
 		switch w { // command
 		case '\'': // 1: quoted
 			asis = 1
 			continue
+		case ']', '}': // block or repeat end
+			return oi, false
 		case '?': // labeled bit
 			c = 48 + byte(from)&1
 			asis = 3
@@ -184,14 +357,14 @@ ploop:
 			}
 		case 'C': // Character 8bit
 			c = byte(from)
-			if c < 32 {
-				c = '~' // make printable
+			if escaped(c) { // snap makes it printable
+				return oi, false
 			}
 			from >>= 8
 		case 'A': // Ascii 7bit
 			c = byte(from) & 0x7f
-			if c < 32 {
-				c = '~'
+			if escaped(c) {
+				return oi, false
 			}
 			from >>= 7
 		case '=': // lowercase if UNSET (0)
@@ -218,26 +391,18 @@ ploop:
 			c = 48 + byte(from)&3
 			from >>= 2
 		case '@': // skip, Dec, Internet bitcount @33!
-			k := (10 * uint8(pic[pi-2]-48)) + uint8(pic[pi-1]-48)
-			var d = 4
-			if k > 16 {
-				d = int(k / 3)
+			var k uint8
+			if pi > 1 {
+				k = (10 * uint8(pic[pi-2]-48)) + uint8(pic[pi-1]-48)
 			}
 			switch {
 			case k == 0, k > 64:
-				fallthrough
-			default:
-				e := `PICERR!`
-				for i := 6; oi > 0 && i >= 0; i-- {
-					oi--
-					ot[oi] = e[i]
-				}
-				break ploop
 			case pi > 2 && pic[pi-3] == '!': // !dd@ skip dd bits
 				pi -= 3
 				from >>= k
-			case pi > d-1 && pic[pi-d] == 'D': // D.dd@ Decimal
-				pi -= d
+				continue
+			case k <= 16 && pi > 3 && pic[pi-4] == 'D': // D.dd@ Decimal
+				pi -= 4
 				v := from &^ (0xFFFFffffFFFFffff << k)
 				from >>= k
 				for v > 9 {
@@ -248,7 +413,9 @@ ploop:
 				}
 				oi--
 				ot[oi] = byte(48 + v)
-			case pi > 13 && pic[pi-14] == 'I': // I##.###.###.32@ Ip v4, 32bit
+				continue
+			case k == 32 && pi > 13 && pic[pi-14] == 'I' && pic[pi-10] != 'D' &&
+				pic[pi-4] != 'B' && pic[pi-3] > '0' && pic[pi-3] != 't': // Ip v4, not other @ of at
 				pi -= 14
 				for i := 0; i < 4; i++ {
 					v := byte(from)
@@ -266,18 +433,344 @@ ploop:
 						ot[oi] = '.'
 					}
 				}
+				continue
 			}
+			return oi, false // PICERR and other @ are for snap
 		default:
 			c = w // as-is
 		}
 		if c != 0 {
 			oi--
 			ot[oi] = c
+		}
+	}
+	return oi, true
+}
+
+// cmd runs the command that ends at pic[pi]. It fills ot leftwards from oi
+// and returns the pic index the command starts at, bits taken, new label
+// state asis, ot and oi. Unknown @ command shows as PICERR! and returns
+// false, as it ends the pic.
+func cmd(pic string, pi int, from uint64, asis byte, ot []byte, oi int, p *peek) (int, uint, byte, []byte, int, bool) {
+	var n uint
+	var c byte
+	switch w := pic[pi]; w {
+	case '?': // labeled bit
+		c = 48 + byte(from)&1
+		asis = 3
+		n = 1
+	case 'B': // Bit
+		c = 48 + byte(from)&1
+		n = 1
+	case 'H': // Hex, usually seen in flock
+		for x := from; ; x >>= 4 {
+			c = byte(x) & 15
+			if c < 10 {
+				c += 0x30
+			} else {
+				c += 0x37
+			}
+			n += 4
+			if pi > 0 && pic[pi-1] == 'H' {
+				pi--
+				oi--
+				ot[oi] = c
+				continue
+			}
+			break
+		}
+	case 'C': // Character 8bit
+		c = byte(from)
+		if escaped(c) { // make printable
+			ot, oi = grow(ot, oi, pi+4)
+			oi = unprint(ot, oi, c)
+			c = 0
+		}
+		n = 8
+	case 'A': // Ascii 7bit
+		c = byte(from) & 0x7f
+		if escaped(c) {
+			ot, oi = grow(ot, oi, pi+4)
+			oi = unprint(ot, oi, c)
 			c = 0
 		}
+		n = 7
+	case '=': // lowercase if UNSET (0)
+		asis = 2 + byte(from)&1 // 2: lower
+		n = 1
+	case '>': // emit label if SET (1)
+		asis = 4 - byte(from)&1 // 3: emit
+		n = 1
+	case '<': // emit label if UNSET (0)
+		asis = 3 + byte(from)&1 // 4: skip
+		n = 1
+	case 'F': // Three
+		c = 48 + byte(from)&7
+		n = 3
+	case 'G': // emit C32s codes as Ascii
+		c = byte(from) & 0x1f
+		if c < 26 {
+			c += 97 // 65 for C32S
+		} else {
+			c += 24
+		}
+		n = 5
+	case 'E': // Duo
+		c = 48 + byte(from)&3
+		n = 2
+	case '@': // skip, Dec, Internet bitcount @33!
+		var ok bool
+		if pi, n, ot, oi, ok = at(pic, pi, from, ot, oi, p); !ok {
+			return pi, 0, asis, ot, oi, false
+		}
+	default:
+		c = w // as-is
+	}
+	if c != 0 {
+		oi--
+		ot[oi] = c
+	}
+	return pi, n, asis, ot, oi, true
+}
+
+// at runs the dd@ command that ends at pic[pi]. It fills ot leftwards from
+// oi and returns the pic index the command starts at, bits taken and both
+// ot and oi. Unknown command shows as PICERR! and returns false.
+func at(pic string, pi int, from uint64, ot []byte, oi int, p *peek) (int, uint, []byte, int, bool) {
+	var n uint
+	var c byte
+	var k uint8 // 0 for @ without bitcount
+	if pi > 1 {
+		k = (10 * uint8(pic[pi-2]-48)) + uint8(pic[pi-1]-48)
+	}
+	var d = 4
+	if k > 16 {
+		d = int(k / 3)
+	}
+	switch {
+	case k == 0, k > 64:
+		fallthrough
+	default:
+		if p != nil && p.chk && p.err == nil {
+			p.err = &PicError{pi, "bad bitcount or unknown @ command"}
+		}
+		e := `PICERR!`
+		for i := 6; oi > 0 && i >= 0; i-- {
+			oi--
+			ot[oi] = e[i]
+		}
+		return pi, 0, ot, oi, false
+	case pi > 2 && pic[pi-3] == '!': // !dd@ skip dd bits
+		pi -= 3
+		n = uint(k)
+		if p != nil && p.nb < 64 {
+			p.dif &^= (0xFFFFffffFFFFffff >> (64 - k)) << p.nb
+		}
+	case pi > 3 && pic[pi-4] == 'B': // B_dd@ Binary, grouped
+		s := pic[pi-3]
+		pi -= 4
+		n = uint(k)
+		g := int(k)
+		if s != '.' {
+			g += int(k-1) / 4
+		}
+		ot, oi = grow(ot, oi, pi+g)
+		v := from
+		for i := 1; ; i++ {
+			oi--
+			ot[oi] = byte(48 + v&1)
+			if i == int(k) {
+				break
+			}
+			v >>= 1
+			if s != '.' && i&3 == 0 {
+				oi--
+				ot[oi] = s
+			}
+		}
+	case pi > 4 && pic[pi-5] == 'G' && pic[pi-4]-48 < 10 && pic[pi-3] == '.' &&
+		(len(Alphabets[pic[pi-4]-48]) == 32 || len(Alphabets[pic[pi-4]-48]) == 64):
+		a := Alphabets[pic[pi-4]-48] // Ga.dd@ Alphabet chars
+		pi -= 5
+		n = uint(k)
+		ot, oi = grow(ot, oi, pi+13)
+		oi = chars(ot, oi, from, n, a)
+	case pi > 5 && pic[pi-6] == 'T' && isUnit(pic[pi-5]) &&
+		pic[pi-4]-48 < 10 && pic[pi-3] == '.': // Tue.dd@ Timestamp
+		u, e := pic[pi-5], Epochs[pic[pi-4]-48]
+		pi -= 6
+		n = uint(k)
+		ot, oi = grow(ot, oi, pi+48)
+		oi = stamp(ot, oi, from&^(0xFFFFffffFFFFffff<<k), u, e)
+	case pi > 4 && pic[pi-5] == 'P' && isUnit(pic[pi-4]) &&
+		pic[pi-3] == '.': // Pu.dd@ Period
+		u := pic[pi-4]
+		pi -= 5
+		n = uint(k)
+		ot, oi = grow(ot, oi, pi+40)
+		oi = period(ot, oi, from&^(0xFFFFffffFFFFffff<<k), u)
+	case pi > 6 && (k == 16 || k == 32) && pic[pi-7:pi-2] == "Float":
+		pi -= 7 // Float16@ Float32@ IEEE-754
+		n = uint(k)
+		ot, oi = grow(ot, oi, pi+16)
+		oi = float(ot, oi, from, n)
+	case pi > 7 && (pic[pi-8] == 'Q' || pic[pi-8] == 'U') &&
+		pic[pi-5] == '.' && pic[pi-3] == '.' && pic[pi-4]-48 < 10 &&
+		pic[pi-7]-48 < 10 && pic[pi-6]-48 < 10 &&
+		10*(pic[pi-7]-48)+pic[pi-6]-48 <= k: // Qff.p.dd@ Fixed point
+		f := uint(10*(pic[pi-7]-48) + pic[pi-6] - 48)
+		pi -= 8
+		n = uint(k)
+		ot, oi = grow(ot, oi, pi+31)
+		oi = format.Fixed(ot, oi, from, n, f, uint(pic[pi+4]-48), pic[pi] == 'Q')
+	case pi > 3 && (pic[pi-3] == '.' || pic[pi-3] == '0') &&
+		(pic[pi-4] == 'X' || pic[pi-4] == 'x' || pic[pi-4] == 'O'):
+		// X.dd@ x.dd@ O.dd@ Hex, hex, Octal. X0dd@ zero padded.
+		z, s, a := pic[pi-3] == '0', uint(4), byte(0x37)
+		switch pic[pi-4] {
+		case 'O':
+			s = 3
+		case 'x':
+			a = 0x57
+		}
+		pi -= 4
+		n = uint(k)
+		ot, oi = grow(ot, oi, pi+22)
+		v := from &^ (0xFFFFffffFFFFffff << k)
+		for i := uint(0); i < n; i += s {
+			c = byte(v) & byte(1<<s-1)
+			if c < 10 {
+				c += 0x30
+			} else {
+				c += a
+			}
+			oi--
+			ot[oi] = c
+			if v >>= s; v == 0 && !z {
+				break
+			}
+		}
+		c = 0
+	case pi > d-1 && pic[pi-d] == 'D': // D.dd@ Decimal
+		pi -= d
+		v := from &^ (0xFFFFffffFFFFffff << k)
+		n = uint(k)
+		for v > 9 {
+			k := v / 10
+			oi--
+			ot[oi] = byte(48 + v - k*10)
+			v = k
+		}
+		oi--
+		ot[oi] = byte(48 + v)
+	case pi > 13 && pic[pi-14] == 'I': // I##.###.###.32@ Ip v4, 32bit
+		pi -= 14
+		x := from
+		for i := 0; i < 4; i++ {
+			v := byte(x)
+			x >>= 8
+			for v > 9 {
+				k := v / 10
+				oi--
+				ot[oi] = byte(48 + v - k*10)
+				v = k
+			}
+			oi--
+			ot[oi] = byte(48 + v)
+			if i < 3 {
+				oi--
+				ot[oi] = '.'
+			}
+		}
+		n = 32
+	}
+	return pi, n, ot, oi, true
+}
+
+// peek carries state of Snap variants. Plain Snap runs with nil *peek.
+type peek struct {
+	dif  uint64   // SnapDiff: bits that changed
+	mark bool     // SnapDiff: put DiffMark around changed fields
+	only bool     // SnapChanged: drop unchanged fields
+	fb   uint     // bit the open field starts at
+	fe   int      // open field output ends at len(ot)-fe
+	fs   []dfield // fields to mark, or to drop, the rightmost first
+
+	multi bool       // SnapN: #d switches words
+	cur   int        // SnapN: word in use
+	words [10]uint64 // SnapN: what is left of each word
+
+	chk bool      // Check: validate pic
+	nb  uint      // Check: bits taken by pic
+	err *PicError // Check: first problem found
+
+	pack   bool     // Pack: collect fields
+	pic    string   // Pack: pic parsed
+	cs     int      // Pack: pic index the last command starts at
+	fields []pfield // Pack: fields found, the rightmost first
+
+	size  bool      // MaxLen: collect commands
+	spans []span    // MaxLen: commands run, the rightmost first
+	sels  []pswitch // MaxLen: switch selectors met
+}
+
+// blk is a block being parsed. Switch blocks are parsed twice: first their
+// last variant is parsed as it is met, then, if selector tells so, another.
+type blk struct {
+	j, k  int        // indices of [ and ]
+	v     int        // index of | or [ that ends parsing
+	e     int        // output of block ends at len(ot)-e
+	s     int        // variant selected, -1 for first run
+	sw    uint       // selector width in bits, 0 for > < conditionals
+	f, fw uint64     // from at block start and at selector
+	nb, w uint       // bits taken before block, by variant
+	cur   int        // SnapN: word in use at block start
+	ws    [10]uint64 // SnapN: words at block start
+	pn    int        // SnapDiff: fields closed before block
+	pb    uint       // SnapDiff: open field at block start: its bit
+	pe    int        // and its output end
+}
+
+// dfield is a field closed by SnapDiff. Its output is at len(ot)-s up to
+// len(ot)-e, where ot is made to the end of pic.
+type dfield struct {
+	e, s int  // output end and start
+	fb   uint // bit it starts at
+}
+
+// pfield is a named field of pic that Pack fills.
+type pfield struct {
+	name  string // first word of its text
+	at    int    // pic index of its command
+	lo, w uint   // lowest bit and width
+}
+
+// span is a command run by snap.
+type span struct {
+	pi    int  // pic index the command starts at
+	lo, w uint // its lowest bit and width
+}
+
+// pswitch is a switch block met by snap.
+type pswitch struct {
+	lo uint // lowest bit of selector
+	n  int  // variants
+}
+
+// grow returns ot with room for at least n bytes on the left of oi.
+// Output already made, ie. ot[oi:], is kept at the end of new ot. Spare
+// capacity of ot is used before the heap is.
+func grow(ot []byte, oi, n int) ([]byte, int) {
+	if oi >= n {
+		return ot, oi
 	}
-	if oi > 0 {
-		return ot[oi:]
+	l := len(ot) - oi
+	var nt []byte
+	if 2*n+l <= cap(ot) {
+		nt = ot[:2*n+l]
+	} else {
+		nt = make([]byte, 2*n+l)
 	}
-	return ot
+	copy(nt[2*n:], ot[oi:])
+	return nt, 2 * n
 }
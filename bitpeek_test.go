@@ -2,11 +2,14 @@
 // Use of this source code is governed by a MIT
 // license that can be found in the LICENSE file.
 
+//go:build !bitpeek_tiny
+
 package bitpeek
 
 import (
 	"fmt"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -192,6 +195,16 @@ func ExampleSnap_decimals() {
 	// D60...............60@   D61...............61@   D62...............62@
 	// D63................63@  D64................64@
 }
+func ExampleSnap_fixedPoint() {
+	// ADC word: 12 bit unsigned Q4.8 volts, then 16 bit signed Q8.8 celsius
+	var adc uint64 = 0x4c0e680
+
+	fmt.Printf("%s\n", Snap(`'U:'U08.2.12@'V T:'Q08.1.16@'C'`, adc))
+
+	// Output:
+	// U:4.75V T:-25.5C
+}
+
 func bigDecTestPictures() {
 	var fil string = `...........................`
 	var d, e, f int
@@ -560,11 +573,205 @@ var parseTests = []struct {
 	{0xdeadbeef, `IP v4 err`, `IPv4.Addres32@`, `PICERR!`, `IP v4 err`},
 	//bitpeek:address:1
 	{0xdeadbeef, `IP v4 ok`, `IPv4.Address32@`, `222.173.190.239`, `IP v4 err`},
+	// Conditional blocks
+	//bitpeek:block:1
+	{0xfdf, `Block shown`, `'EXT>[ Id:0xFHH]`, `EXT Id:0x7DF`, `block`},
+	//bitpeek:block:1
+	{0x07df, `Block hidden`, `'EXT>[ Id:0xFHH]`, ``, `block`},
+	//bitpeek:block:1
+	{0x07df, `Block unset`, `'short<[ Id:0xFHH]`, `short Id:0x7DF`, `block`},
+	//bitpeek:block:1
+	{0xfdf, `Block bits taken`, `F 'EXT>[ Id:0xFHH]`, `0 EXT Id:0x7DF`, `block`},
+	//bitpeek:block:1
+	{0x07df, `Block hidden bits`, `F 'EXT>[ Id:0xFHH]`, `0 `, `block`},
+	//bitpeek:block:1
+	{0x7, `Block nested`, `'a>[ 'b>[ x]? y]`, `a b x1 y`, `block`},
+	//bitpeek:block:1
+	{0x1, `Block nested hid`, `'a>[ 'b>[ x]? y]`, ``, `block`},
+	//bitpeek:block:1
+	{0x4, `Block nested in`, `'a>[ 'b>[ x]? y]`, `a 0 y`, `block`},
+	//bitpeek:block:1
+	{0x2ab, `Block ends label`, `'A>[HH]ERR>`, `A55ERR`, `block`},
+	//bitpeek:block:1
+	{0xab, `Block not glued`, `x:[HH]`, `x:[AB]`, `block`},
+	//bitpeek:block:1
+	{0x1ab, `Block escaped`, `'A>\[HH\]`, `A[AB]`, `block`},
+	//bitpeek:block:1
+	{0x1ab, `Block unclosed`, `'A>[HH`, `A[AB`, `block`},
+	//bitpeek:block:1
+	{0xab, `Block quoted`, `'A>[HH]'`, `A>[HH]`, `block`},
+	//bitpeek:switch:1
+	{0x0a5, `Switch first`, `'T:'F[ ping:HH| len:D.05@ ch:E!01@| raw:HH]`, `T:0 ping:A5`, `block`},
+	//bitpeek:switch:1
+	{0x1a5, `Switch middle`, `'T:'F[ ping:HH| len:D.05@ ch:E!01@| raw:HH]`, `T:1 len:20 ch:2`, `block`},
+	//bitpeek:switch:1
+	{0x2a5, `Switch last`, `'T:'F[ ping:HH| len:D.05@ ch:E!01@| raw:HH]`, `T:2 raw:A5`, `block`},
+	//bitpeek:switch:1
+	{0x7a5, `Switch unknown`, `'T:'F[ ping:HH| len:D.05@ ch:E!01@| raw:HH]`, `T:7`, `block`},
+	//bitpeek:switch:1
+	{0x2ff, `Switch in front`, `'X'E[ a|b]' 'D.10@`, `X0 a 767`, `block`},
+	//bitpeek:switch:1
+	{0x1ab, `Switch labels`, `B[ 'RX=.TX=| HH!06@]`, `0 RX.TX`, `block`},
+	//bitpeek:switch:1
+	{0x3ab, `Switch dd@ sel`, `D.02@[a|b|c|d]`, `3d`, `block`},
+	//bitpeek:switch:1
+	{0x195, `Switch in block`, `'n>[ F[x:H|y:H]]`, `n 1y:5`, `block`},
+	//bitpeek:switch:1
+	{0x15, `Switch nested`, `E[ B[a|b]H| c:H!01@]`, `0 1b5`, `block`},
+	//bitpeek:switch:1
+	{0xab, `Switch escaped bar`, `B[a\|b]HH`, `0a|bAB`, `block`},
+	//bitpeek:switch:1
+	{0xab, `Switch bar in cond`, `'A>[ a|b]`, `A a|b`, `block`},
+	//bitpeek:repeat:1
+	{0x4142434445a5, `Repeat hex`, `H{4}`, `45A5`, `repeat`},
+	//bitpeek:repeat:1
+	{0x45a5, `Repeat bits`, `B{16/4}`, `0100 0101 1010 0101`, `repeat`},
+	//bitpeek:repeat:1
+	{0x45a5, `Repeat bits sep`, `B{16/4_}`, `0100_0101_1010_0101`, `repeat`},
+	//bitpeek:repeat:1
+	{0x45a5, `Repeat bits nogroup`, `B{6}`, `100101`, `repeat`},
+	//bitpeek:repeat:1
+	{0x45a5, `Repeat flock`, `HH{2}`, `45A5`, `repeat`},
+	//bitpeek:repeat:1
+	{0x45a5, `Repeat decimals`, `x:D.04@{3/1,}`, `x:5,10,5`, `repeat`},
+	//bitpeek:repeat:1
+	{0x4142434445a5, `Repeat chars`, `C{3}'|'H`, `4DZ|5`, `repeat`},
+	//bitpeek:repeat:1
+	{0x45a5, `Repeat in label`, `'a:'E{3}'!'`, `a:211!`, `repeat`},
+	//bitpeek:repeat:1
+	{0x45a5, `Repeat zero`, `H{0}`, `5{0}`, `repeat`},
+	//bitpeek:repeat:1
+	{0x45a5, `Repeat escaped`, `\H{2}`, `H{2}`, `repeat`},
+	//bitpeek:repeat:1
+	{0x45a5, `Repeat not cmd`, `x{2}`, `x{2}`, `repeat`},
+	//bitpeek:repeat:1
+	{0x45a5, `Repeat quoted`, `'H{2}'`, `H{2}`, `repeat`},
+	//bitpeek:repeat:1
+	{0x45a5, `Repeat bad group`, `H{2/2}`, `5{2/2}`, `repeat`},
+	//bitpeek:repeat:1
+	{0x45a5, `Repeat then cmd`, `B{8/4} F`, `1011 0100 5`, `repeat`},
+	//bitpeek:binary:1
+	{0x5c3, `Binary grouped`, `B_12@`, `0101_1100_0011`, `binary`},
+	//bitpeek:binary:1
+	{0x5c3, `Binary plain`, `B.12@`, `010111000011`, `binary`},
+	//bitpeek:binary:1
+	{0x5c3, `Binary odd`, `B:06@`, `00:0011`, `binary`},
+	//bitpeek:binary:1
+	{0x5c3, `Binary one`, `'x'B 05@`, `x0 0011`, `binary`},
+	//bitpeek:binary:1
+	{0xafdfdeadbeef4d0e, `Binary 64`, `B_64@`, `1010_1111_1101_1111_1101_1110_1010_1101_1011_1110_1110_1111_0100_1101_0000_1110`, `binary`},
+	//bitpeek:binary:1
+	{0x5c3, `Binary then`, `'r:'B 04@ 'v:'B.08@`, `r:0101 v:11000011`, `binary`},
+	//bitpeek:binary:1
+	{0x5c3, `Binary repeat`, `B_04@{3/1|}`, `0101|1100|0011`, `binary`},
+	//bitpeek:hexoct:1
+	{0x3a7, `Hex 10 bits`, `X.10@`, `3A7`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0x3a7, `Hex lower`, `0xx.10@`, `0x3a7`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0x3a7, `Hex padded`, `X012@`, `3A7`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0x3a7, `Hex padded odd`, `X016@`, `03A7`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0x3a7, `Hex minimal`, `X.16@`, `3A7`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0x0, `Hex zero`, `X.16@`, `0`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0x0, `Hex zero pad`, `x013@`, `0000`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0xfedcba9876543210, `Hex 64`, `X.64@`, `FEDCBA9876543210`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0xfedcba9876543210, `Hex 64 low`, `x064@`, `fedcba9876543210`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0x1ff, `Octal 9 bits`, `O.09@`, `777`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0x1ff, `Octal 8 bits`, `O.08@`, `377`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0x9, `Octal padded`, `O010@`, `0011`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0xffffffffffffffff, `Octal 64`, `O.64@`, `1777777777777777777777`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0x3a7, `Hex then`, `'a:'O.03@ 'b:'X.07@`, `a:7 b:27`, `hexoct`},
+	//bitpeek:hexoct:1
+	{0x3a7, `Hex bad sep`, `X:10@`, `CERR!`, `hexoct`},
+	//bitpeek:fixed:1
+	{0x1980, `Fixed Q8.8`, `Q08.2.16@`, `25.50`, `fixed`},
+	//bitpeek:fixed:1
+	{0xe680, `Fixed Q8.8 neg`, `Q08.2.16@`, `-25.50`, `fixed`},
+	//bitpeek:fixed:1
+	{0xff01, `Fixed U8.8`, `U08.3.16@`, `255.004`, `fixed`},
+	//bitpeek:fixed:1
+	{0x18, `Fixed round int`, `Q04.0.08@`, `2`, `fixed`},
+	//bitpeek:fixed:1
+	{0xff, `Fixed small neg`, `Q04.1.08@`, `-0.1`, `fixed`},
+	//bitpeek:fixed:1
+	{0xffff, `Fixed neg zero`, `Q08.2.16@`, `-0.00`, `fixed`},
+	//bitpeek:fixed:1
+	{0x9ff, `Fixed round carry`, `U08.2.12@`, `10.00`, `fixed`},
+	//bitpeek:fixed:1
+	{0xc8, `Fixed integer`, `U00.2.08@`, `200.00`, `fixed`},
+	//bitpeek:fixed:1
+	{0x4000000000000000, `Fixed U0.64`, `U63.9.64@`, `0.500000000`, `fixed`},
+	//bitpeek:fixed:1
+	{0x8000000000000000, `Fixed Q0.64`, `Q64.9.64@`, `-0.500000000`, `fixed`},
+	//bitpeek:fixed:1
+	{0x7fffffffffffffff, `Fixed Q64 max`, `Q00.0.64@`, `9223372036854775807`, `fixed`},
+	//bitpeek:fixed:1
+	{0x1ff, `Fixed in label`, `'T:'U08.1.08@' C=`, `T:1.0 C`, `fixed`},
+	//bitpeek:fixed:1
+	{0x1980, `Fixed frac > bits`, `Q17.2.16@`, `PICERR!`, `fixed`},
+	//bitpeek:float:1
+	{0x3c00, `Float16 one`, `Float16@`, `1`, `float`},
+	//bitpeek:float:1
+	{0x3555, `Float16 third`, `Float16@`, `0.3333`, `float`},
+	//bitpeek:float:1
+	{0xc000, `Float16 neg`, `Float16@`, `-2`, `float`},
+	//bitpeek:float:1
+	{0x7bff, `Float16 max`, `Float16@`, `65500`, `float`},
+	//bitpeek:float:1
+	{0x0001, `Float16 denormal`, `Float16@`, `6e-08`, `float`},
+	//bitpeek:float:1
+	{0x8000, `Float16 neg zero`, `Float16@`, `-0`, `float`},
+	//bitpeek:float:1
+	{0x7c00, `Float16 inf`, `Float16@`, `+Inf`, `float`},
+	//bitpeek:float:1
+	{0xfc00, `Float16 neg inf`, `Float16@`, `-Inf`, `float`},
+	//bitpeek:float:1
+	{0x7e00, `Float16 NaN`, `Float16@`, `NaN`, `float`},
+	//bitpeek:float:1
+	{0x3dcccccd, `Float32 tenth`, `Float32@`, `0.1`, `float`},
+	//bitpeek:float:1
+	{0x7f7fffff, `Float32 max`, `Float32@`, `3.4028235e+38`, `float`},
+	//bitpeek:float:1
+	{0x00000001, `Float32 denormal`, `Float32@`, `1e-45`, `float`},
+	//bitpeek:float:1
+	{0x49742400, `Float32 million`, `Float32@`, `1e+06`, `float`},
+	//bitpeek:float:1
+	{0x3c003dcccccd, `Float pair`, `'h:'Float16@' s:'Float32@`, `h:1 s:0.1`, `float`},
+	//bitpeek:float:1
+	{0x3c00, `Float bad width`, `Float15@`, `PICERR!`, `float`},
+	//bitpeek:time:1
+	{1700000000, `Time Unix`, `Ts0.32@`, `2023-11-14T22:13:20Z`, `time`},
+	//bitpeek:time:1
+	{0, `Time NTP`, `Ts1.32@`, `1900-01-01T00:00:00Z`, `time`},
+	//bitpeek:time:1
+	{1700000000, `Time bad unit`, `Tx0.32@`, `PICERR!`, `time`},
+	//bitpeek:time:1
+	{3723, `Period`, `'up 'Ps.16@`, `up 1h2m3s`, `time`},
+	//bitpeek:time:1
+	{1500, `Period ns`, `Pn.16@`, `1.5µs`, `time`},
 	// Octals and mixes
 	//bitpeek:octal:1
 	{0xdeadbeef, `Bad octal `, `FFF`, `357`, `char`},
 	//bitpeek:octal:1
 	{0xdeadbeef, `Force Bad octal `, `F''F''F`, `357`, `char`},
+	// Fuzzer finds
+	//bitpeek:fuzz:1
+	{0x55, `Fuzz bare at`, `@`, `!`, `fuzz`},
+	//bitpeek:fuzz:1
+	{0x55, `Fuzz one digit at`, `5@`, `R!`, `fuzz`},
+	//bitpeek:fuzz:1
+	{0x55, `Fuzz NUL`, "'x'\x00", `x`, `fuzz`},
 	//	{0x5, `Label `, ``, `x`, `char`},
 }
 
@@ -693,3 +900,84 @@ func BenchmarkLong(b *testing.B) {
 `, header)
 	}
 }
+
+// go test -run=XXX -fuzz=FuzzSnap -fuzztime=60s
+// Crashers land in testdata/fuzz/FuzzSnap, add them to parseTests too.
+// Besides Snap it runs compiled pics, SnapDiff, SnapChanged, SnapN, Len,
+// AppendSnap, Check and Pack of the same pic. Pics with # go to SnapN, that
+// must read words[0] on the left of the first #d.
+func FuzzSnap(f *testing.F) {
+	defer func(m [2]string) { DiffMark = m }(DiffMark)
+	DiffMark = [2]string{"\x01", "\x02"}
+	for _, v := range parseTests {
+		f.Add(v.pic, v.inp)
+	}
+	for _, v := range xparseTests {
+		f.Add(v.pic, v.inp)
+	}
+	for _, v := range diffTests {
+		f.Add(v.pic, v.now)
+	}
+	for _, v := range checkTests {
+		f.Add(v.pic, uint64(0x5a5a5a5a5a5a5a5a))
+	}
+	for _, v := range multiTests {
+		f.Add(v.pic, uint64(0xa5a5a5a5a5a5a5a5))
+	}
+	f.Fuzz(func(t *testing.T, pic string, from uint64) {
+		o := Snap(pic, from)
+		if g := compile(pic); g != nil {
+			if c := g.run(pic, from); string(c) != string(o) {
+				t.Errorf("compiled %q of %#x is broken! o≢e\n>%s<\n>%s<", pic, from, c, o)
+			}
+		}
+		if l := Len(pic, from); l != len(o) {
+			t.Errorf("Len of %q of %#x is %d, Snap is %d long", pic, from, l, len(o))
+		}
+		if a := AppendSnap([]byte("x"), pic, from); string(a) != "x"+string(o) {
+			t.Errorf("AppendSnap %q of %#x is broken! o≢e\n>%s<\n>x%s<", pic, from, a, o)
+		}
+		if !strings.Contains(pic, "#") {
+			if n := SnapN(pic, from); string(n) != string(o) {
+				t.Errorf("SnapN %q of %#x is broken! o≢e\n>%s<\n>%s<", pic, from, n, o)
+			}
+		} else if n, z := SnapN(pic, from, ^from), SnapN("#0"+pic, from, ^from); string(n) != string(z) &&
+			Check(pic) == nil { // PICERR is cut to the room pic gives
+			t.Errorf("SnapN %q of %#x is broken by #0 ahead! o≢e\n>%s<\n>%s<", pic, from, z, n)
+		}
+		was := from ^ from>>7 ^ 1
+		d := string(SnapDiff(pic, was, from))
+		c := string(SnapChanged(pic, was, from))
+		if !strings.ContainsAny(pic, "\x01\x02") {
+			if u := strings.NewReplacer("\x01", "", "\x02", "").Replace(d); u != string(o) {
+				t.Errorf("SnapDiff %q of %#x is not Snap! o≢e\n>%q<\n>%q<", pic, from, u, o)
+			}
+			for _, x := range strings.Split(d, "\x01")[1:] {
+				if m := strings.Split(x, "\x02"); len(m) != 2 || !strings.Contains(c, m[0]) {
+					t.Errorf("SnapDiff %q of %#x >%q< does not fit SnapChanged >%q<", pic, from, d, c)
+				}
+			}
+		}
+		if Check(pic) != nil {
+			return
+		}
+		p := &peek{pack: true, pic: pic} // pack fields of from back
+		snap(pic, 0, make([]byte, len(pic)), len(pic), p)
+		fields := map[string]uint64{}
+		var mask uint64
+		for _, x := range p.fields {
+			if _, ok := fields[x.name]; ok || x.lo >= 64 || x.w == 0 {
+				return // many fields of a name get the same value
+			}
+			m := ^uint64(0)
+			if x.w < 64 {
+				m = 1<<x.w - 1
+			}
+			fields[x.name] = from >> x.lo & m
+			mask |= m << x.lo
+		}
+		if u, err := Pack(pic, fields); err == nil && u != from&mask {
+			t.Errorf("Pack %q of %#x fields gave %#x, want %#x", pic, from, u, from&mask)
+		}
+	})
+}
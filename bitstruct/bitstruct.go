@@ -0,0 +1,314 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+// Package bitstruct shows Go structs that mirror packed registers. Fields
+// tagged with their bit width are packed into an uint64 and rendered by
+// bitpeek.Snap with a picstring made of the struct type itself:
+//
+//	type Ctl struct {
+//		Type uint8  `bitpeek:"3"`
+//		Ext  bool   `bitpeek:"1"`
+//		Ack  bool   `bitpeek:"1"`
+//		Id   uint16 `bitpeek:"11,X"`
+//	}
+//
+//	out, _ := bitstruct.Snap(Ctl{Type: 5, Ack: true, Id: 0x7df})
+//
+//	Output:
+//	Type:5 ext Ack Id:7DF
+//
+// The first tagged field takes the most significant bits, same as the pic
+// shows b63 on its left. Fields sum up to at most 64 bits and are packed to
+// the low bits of the word, so a 16 bit struct reads as an uint16 would.
+// Fields with no tag, or with `bitpeek:"-"`, are left out.
+//
+// Tag is `bitpeek:"w"` or `bitpeek:"w,f"` where w is a width in bits and f
+// picks how the field shows:
+//
+//	=  label, lowercased if 0. Default for bool and one bit fields. One
+//	   bit ints are flags too, of 0 or 1.
+//	?  label with 0 or 1 digit.
+//	>  label only if 1.  < label only if 0.
+//	D  decimal. Default for unsigned fields.
+//	Q  two's complement decimal. Default for signed fields.
+//	X  hex number, x for lowercase digits, O for octal.
+//	B  binary digits, grouped by four.
+//	A  7 bit ascii character, C 8 bit one.
+//
+// PackPic fills a hand written pic from struct fields of the same names, so
+// the pic stays the one definition of a layout.
+//
+// Bitstruct uses reflect, so it is an opt-in companion to the zero
+// dependency bitpeek. Layouts are worked out once per struct type.
+package bitstruct
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ohir/bitpeek"
+)
+
+// field is a tagged struct field.
+type field struct {
+	i    int    // index in struct
+	name string // Go name
+	w    uint   // width in bits
+	f    byte   // format
+	sign bool   // int kinds over 1 bit
+}
+
+// layout is what a struct type packs to.
+type layout struct {
+	fs  []field
+	pic string
+}
+
+var layouts sync.Map // reflect.Type to *layout
+
+// Func Pic returns the picstring that Snap uses for v, a struct or a pointer
+// to struct. Labels are field names, widths are from tags.
+func Pic(v interface{}) (string, error) {
+	l, _, err := of(v)
+	if err != nil {
+		return "", err
+	}
+	return l.pic, nil
+}
+
+// Func Pack packs tagged fields of v into an uint64. It fails if a field
+// value does not fit its width.
+func Pack(v interface{}) (uint64, error) {
+	l, rv, err := of(v)
+	if err != nil {
+		return 0, err
+	}
+	return l.pack(rv)
+}
+
+// Func Snap packs v and renders it with its Pic.
+func Snap(v interface{}) ([]byte, error) {
+	l, rv, err := of(v)
+	if err != nil {
+		return nil, err
+	}
+	u, err := l.pack(rv)
+	if err != nil {
+		return nil, err
+	}
+	return bitpeek.Snap(l.pic, u), nil
+}
+
+// Func PackPic is bitpeek.Pack with field values taken from v, a struct or
+// a pointer to struct. Pic fields are named after struct fields, tags are
+// not used here except `bitpeek:"-"` that leaves a field out. Fields of
+// other than bool and integer kinds are left out too.
+//
+//	type Hdr struct {
+//		Type uint8
+//		ACK  bool
+//		Id   uint16
+//	}
+//
+//	v, _ := bitstruct.PackPic(`'Type:'F 'EXT=.ACK= Id:0xFHH`,
+//		Hdr{Type: 5, ACK: true, Id: 0x7df}) // v is 0xafdf
+func PackPic(pic string, v interface{}) (uint64, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("bitstruct: %T is not a struct", v)
+	}
+	t := rv.Type()
+	m := make(map[string]uint64, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("bitpeek") == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		switch fv.Kind() {
+		case reflect.Bool:
+			if fv.Bool() {
+				m[t.Field(i).Name] = 1
+			} else {
+				m[t.Field(i).Name] = 0
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			m[t.Field(i).Name] = uint64(fv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			m[t.Field(i).Name] = fv.Uint()
+		}
+	}
+	return bitpeek.Pack(pic, m)
+}
+
+// of returns layout and struct value of v.
+func of(v interface{}) (*layout, reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, rv, fmt.Errorf("bitstruct: %T is not a struct", v)
+	}
+	t := rv.Type()
+	if l, ok := layouts.Load(t); ok {
+		return l.(*layout), rv, nil
+	}
+	l, err := build(t)
+	if err != nil {
+		return nil, rv, err
+	}
+	layouts.Store(t, l)
+	return l, rv, nil
+}
+
+// build reads tags of t and makes its layout.
+func build(t reflect.Type) (*layout, error) {
+	l := &layout{}
+	var pic strings.Builder
+	var nb uint
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("bitpeek")
+		if !ok || tag == "-" {
+			continue
+		}
+		f := field{i: i, name: sf.Name}
+		ws, fs := tag, ""
+		if c := strings.IndexByte(tag, ','); c >= 0 {
+			ws, fs = tag[:c], tag[c+1:]
+		}
+		w, err := strconv.Atoi(ws)
+		if err != nil || w < 1 || w > 64 {
+			return nil, fmt.Errorf("bitstruct: %s.%s: bad width %q", t.Name(), sf.Name, ws)
+		}
+		f.w = uint(w)
+		switch sf.Type.Kind() {
+		case reflect.Bool:
+			if w != 1 {
+				return nil, fmt.Errorf("bitstruct: %s.%s: bool must be 1 bit wide", t.Name(), sf.Name)
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f.sign = w > 1 // one bit is a flag
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			return nil, fmt.Errorf("bitstruct: %s.%s: %s can not be packed", t.Name(), sf.Name, sf.Type)
+		}
+		switch {
+		case len(fs) == 1:
+			f.f = fs[0]
+		case fs != "":
+			return nil, fmt.Errorf("bitstruct: %s.%s: bad format %q", t.Name(), sf.Name, fs)
+		case w == 1:
+			f.f = '='
+		case f.sign:
+			f.f = 'Q'
+		default:
+			f.f = 'D'
+		}
+		sep := " "
+		if pic.Len() == 0 {
+			sep = ""
+		}
+		var cmd string
+		switch f.f {
+		case '=', '?', '>', '<':
+			if w != 1 {
+				return nil, fmt.Errorf("bitstruct: %s.%s: %c label must be 1 bit wide", t.Name(), sf.Name, f.f)
+			}
+			pic.WriteString("'" + sep + f.name + string(f.f))
+			l.fs = append(l.fs, f)
+			nb += f.w
+			continue
+		case 'D':
+			cmd = "D" + strings.Repeat(".", dots(w)) + dd(w)
+		case 'Q':
+			if w == 1 {
+				return nil, fmt.Errorf("bitstruct: %s.%s: Q must be over 1 bit wide", t.Name(), sf.Name)
+			}
+			cmd = "Q00.0." + dd(w)
+		case 'X', 'x', 'O':
+			cmd = string(f.f) + "." + dd(w)
+		case 'B':
+			cmd = "B_" + dd(w)
+		case 'A', 'C':
+			if w != 7 && f.f == 'A' || w != 8 && f.f == 'C' {
+				return nil, fmt.Errorf("bitstruct: %s.%s: %c must be %d bits wide", t.Name(), sf.Name, f.f, 7+int(f.f-'A')/2)
+			}
+			cmd = string(f.f)
+		default:
+			return nil, fmt.Errorf("bitstruct: %s.%s: bad format %q", t.Name(), sf.Name, fs)
+		}
+		pic.WriteString("'" + sep + f.name + ":'" + cmd)
+		l.fs = append(l.fs, f)
+		nb += f.w
+	}
+	if nb > 64 {
+		return nil, fmt.Errorf("bitstruct: %s takes %d bits, more than 64", t.Name(), nb)
+	}
+	if len(l.fs) == 0 {
+		return nil, errors.New("bitstruct: " + t.Name() + " has no tagged fields")
+	}
+	l.pic = pic.String()
+	return l, nil
+}
+
+// pack puts fields of rv into an uint64, the first one on the left.
+func (l *layout) pack(rv reflect.Value) (uint64, error) {
+	var u uint64
+	for _, f := range l.fs {
+		fv := rv.Field(f.i)
+		var x uint64
+		switch {
+		case fv.Kind() == reflect.Bool:
+			if fv.Bool() {
+				x = 1
+			}
+		case f.sign:
+			s := fv.Int()
+			if f.w < 64 && (s >= 1<<(f.w-1) || s < -1<<(f.w-1)) {
+				return 0, fmt.Errorf("bitstruct: %s %d does not fit %d bits", f.name, s, f.w)
+			}
+			x = uint64(s)
+		case fv.CanInt(): // one bit flag
+			if s := fv.Int(); s != 0 && s != 1 {
+				return 0, fmt.Errorf("bitstruct: %s %d does not fit %d bits", f.name, s, f.w)
+			}
+			x = uint64(fv.Int())
+		default:
+			x = fv.Uint()
+			if f.w < 64 && x>>f.w != 0 {
+				return 0, fmt.Errorf("bitstruct: %s %d does not fit %d bits", f.name, x, f.w)
+			}
+		}
+		if f.w < 64 {
+			u = u<<f.w | x&(1<<f.w-1)
+		} else {
+			u = x
+		}
+	}
+	return u, nil
+}
+
+// dd returns the two digit bitcount of a @ command.
+func dd(w int) string {
+	return string([]byte{byte('0' + w/10), byte('0' + w%10), '@'})
+}
+
+// dots returns how many fillers D.dd@ needs between D and its bitcount:
+// Snap wants the pic to be as long as the widest decimal it can show.
+func dots(w int) int {
+	if w > 16 {
+		return w/3 - 3
+	}
+	return 1
+}
@@ -0,0 +1,180 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitstruct
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohir/bitpeek"
+)
+
+type Ctl struct {
+	Type uint8  `bitpeek:"3"`
+	Ext  bool   `bitpeek:"1"`
+	Ack  bool   `bitpeek:"1"`
+	Id   uint16 `bitpeek:"11,X"`
+	note string // not packed
+}
+
+func ExampleSnap() {
+	out, err := Snap(Ctl{Type: 5, Ack: true, Id: 0x7df})
+	fmt.Printf("%s %v\n", out, err)
+	pic, _ := Pic(&Ctl{})
+	fmt.Println(pic)
+
+	// Output:
+	// Type:5 ext Ack Id:7DF <nil>
+	// 'Type:'D.03@' Ext=' Ack=' Id:'X.11@
+}
+
+type Reg struct {
+	Flags  uint8  `bitpeek:"4,B"`
+	Rdy    uint8  `bitpeek:"1,?"`
+	Err    bool   `bitpeek:"1,>"`
+	Ovl    bool   `bitpeek:"1,<"`
+	Temp   int8   `bitpeek:"8"`
+	Oct    uint16 `bitpeek:"9,O"`
+	Ch     byte   `bitpeek:"8,C"`
+	Hex    uint32 `bitpeek:"20,x"`
+	Big    uint16 `bitpeek:"12"`
+	Skip   uint64 `bitpeek:"-"`
+	Unused int
+}
+
+type Flags struct {
+	On  int8 `bitpeek:"1"`
+	Up  int  `bitpeek:"1,>"`
+	Val int8 `bitpeek:"2"`
+}
+
+type Wide struct {
+	A uint64 `bitpeek:"40"`
+	B int32  `bitpeek:"24"`
+}
+
+var packTests = []struct {
+	name string
+	v    interface{}
+	u    uint64
+	out  string
+}{
+	{`ctl`, Ctl{Type: 5, Ack: true, Id: 0x7df}, 0xafdf, `Type:5 ext Ack Id:7DF`},
+	{`ctl zero`, &Ctl{}, 0, `Type:0 ext ack Id:0`},
+	{`reg`, Reg{Flags: 9, Rdy: 1, Ovl: true, Temp: -5, Oct: 0o755, Ch: 'Z', Hex: 0xbeef, Big: 4095},
+		0x9<<60 | 1<<59 | 1<<57 | 0xfb<<49 | 0o755<<40 | 'Z'<<32 | 0xbeef<<12 | 4095,
+		`Flags:1001 Rdy1 Temp:-5 Oct:755 Ch:Z Hex:beef Big:4095`},
+	{`reg err`, Reg{Err: true}, 1 << 58, `Flags:0000 Rdy0 Err Ovl Temp:0 Oct:0 Ch:~ Hex:0 Big:0`},
+	{`int flags`, Flags{On: 1, Val: -2}, 0xa, `On Val:-2`},
+	{`int flags zero`, Flags{Up: 1}, 0x4, `on Up Val:0`},
+	{`wide`, Wide{A: 1<<40 - 1, B: -1}, 0xffffffffffffffff, `A:1099511627775 B:-1`},
+}
+
+func TestPack(t *testing.T) {
+	for _, v := range packTests {
+		u, err := Pack(v.v)
+		if err != nil || u != v.u {
+			t.Errorf("%s: Pack gave %#x, %v; want %#x", v.name, u, err, v.u)
+		}
+		if o, err := Snap(v.v); err != nil || string(o) != v.out {
+			t.Errorf("%s: o≢e >%s< ≢ >%s< %v", v.name, o, v.out, err)
+		}
+		if pic, _ := Pic(v.v); bitpeek.Check(pic) != nil {
+			t.Errorf("%s: bad pic %s: %v", v.name, pic, bitpeek.Check(pic))
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, v := range []struct {
+		v   interface{}
+		err string
+	}{
+		{42, `not a struct`},
+		{struct{}{}, `no tagged fields`},
+		{struct {
+			A uint8 `bitpeek:"x"`
+		}{}, `bad width`},
+		{struct {
+			A uint8 `bitpeek:"65"`
+		}{}, `bad width`},
+		{struct {
+			A bool `bitpeek:"2"`
+		}{}, `bool must be 1 bit`},
+		{struct {
+			A string `bitpeek:"8"`
+		}{}, `can not be packed`},
+		{struct {
+			A uint8 `bitpeek:"8,Z"`
+		}{}, `bad format`},
+		{struct {
+			A uint8 `bitpeek:"8,DD"`
+		}{}, `bad format`},
+		{struct {
+			A uint8 `bitpeek:"2,="`
+		}{}, `label must be 1 bit`},
+		{struct {
+			A uint8 `bitpeek:"8,A"`
+		}{}, `must be 7 bits`},
+		{struct {
+			A uint64 `bitpeek:"60"`
+			B uint64 `bitpeek:"5"`
+		}{}, `more than 64`},
+		{struct {
+			A uint8 `bitpeek:"3"`
+		}{A: 8}, `does not fit`},
+		{struct {
+			A int8 `bitpeek:"3"`
+		}{A: -5}, `does not fit`},
+		{struct {
+			A int8 `bitpeek:"3"`
+		}{A: 4}, `does not fit`},
+		{struct {
+			A int8 `bitpeek:"1"`
+		}{A: -1}, `does not fit`},
+		{struct {
+			A int8 `bitpeek:"1,Q"`
+		}{}, `Q must be over 1 bit`},
+	} {
+		_, err := Snap(v.v)
+		if err == nil || !strings.Contains(err.Error(), v.err) {
+			t.Errorf("%#v: got %v, want %s", v.v, err, v.err)
+		}
+	}
+}
+
+type Hdr struct {
+	Type uint8
+	EXT  bool
+	ACK  bool
+	Id   uint16
+	From uint32 `bitpeek:"-"`
+	Note string
+}
+
+func TestPackPic(t *testing.T) {
+	//bitpeek:pack:1
+	pic := `'Type:'F 'EXT=.ACK= Id:0xFHH`
+	for _, v := range []struct {
+		v   interface{}
+		u   uint64
+		err string
+	}{
+		{Hdr{Type: 5, ACK: true, Id: 0x7df}, 0xafdf, ``},
+		{&Hdr{Type: 8}, 0, `value does not fit Type`},
+		{struct{ Typo int8 }{-1}, 0, `no field named Typo`},
+		{struct{ Type int8 }{-1}, 0xe000, ``},
+		{7, 0, `not a struct`},
+	} {
+		u, err := PackPic(pic, v.v)
+		if u != v.u || (err == nil) != (v.err == "") ||
+			err != nil && !strings.Contains(err.Error(), v.err) {
+			t.Errorf("%#v: got %#x %v, want %#x %s", v.v, u, err, v.u, v.err)
+		}
+	}
+}
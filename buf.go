@@ -0,0 +1,39 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package bitpeek
+
+// Func AppendSnap appends what Snap(pic, from) would return to dst and
+// returns the extended buffer. It makes no garbage if dst has room for the
+// output, and pic and its output are under 256 bytes, so it is the Snap of
+// choice for firmware and for hot paths:
+//
+//    var line [64]byte
+//    out := bitpeek.AppendSnap(line[:0], `'PT:'F 'EXT=.ACK= Id:0xFHH`, 0xbfdf)
+func AppendSnap(dst []byte, pic string, from uint64) []byte {
+	var b [256]byte
+	return append(dst, snapb(&b, pic, from)...)
+}
+
+// Func Len returns len(Snap(pic, from)) without making the output, unless
+// pic is longer than 256 bytes.
+func Len(pic string, from uint64) int {
+	var b [256]byte
+	return len(snapb(&b, pic, from))
+}
+
+// snapb returns output of pic made in b, or on the heap if it does not
+// fit b.
+func snapb(b *[256]byte, pic string, from uint64) []byte {
+	ot, oi := snap(pic, from, buf(b, len(pic)), len(pic), nil)
+	return ot[oi:]
+}
+
+// buf returns n bytes of b, or of the heap if b is too short.
+func buf(b *[256]byte, n int) []byte {
+	if n > len(b) {
+		return make([]byte, n)
+	}
+	return b[:n]
+}
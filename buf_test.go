@@ -0,0 +1,64 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package bitpeek
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func ExampleAppendSnap() {
+	var line [64]byte
+	out := AppendSnap(line[:0], `'PT:'F 'EXT=.ACK= Id:0xFHH`, 0xbfdf)
+	out = AppendSnap(append(out, ' '), `'at 'Ts0.32@`, 1700000000)
+	fmt.Printf("%s\n", out)
+
+	// Output:
+	// PT:5 EXT.ACK Id:0x7DF at 2023-11-14T22:13:20Z
+}
+
+var appendTests = []struct {
+	name string
+	pic  string
+	inp  uint64
+	out  string
+}{
+	//bitpeek:append:1
+	{`chars`, `'PT:'F 'EXT=.ACK= Id:0xFHH`, 0xbfdf, `PT:5 EXT.ACK Id:0x7DF`},
+	//bitpeek:append:1
+	{`wider than pic`, `D.......32@`, 0xffffffff, `4294967295`},
+	//bitpeek:append:1
+	{`time`, `Ts0.32@`, 1700000000, `2023-11-14T22:13:20Z`},
+	//bitpeek:append:1
+	{`block`, `x>[ Id:D.16@]`, 0x1ffff, `x Id:65535`},
+	//bitpeek:append:1
+	{`bad at`, `X:D.99@`, 0, `PICERR!`},
+}
+
+func TestAppendSnap(t *testing.T) {
+	for _, v := range appendTests {
+		o := AppendSnap([]byte(">"), v.pic, v.inp)
+		if string(o) != ">"+v.out || Len(v.pic, v.inp) != len(v.out) {
+			t.Errorf("%s is broken! o≢e >%s< ≢ >%s<", v.name, o[1:], v.out)
+		}
+	}
+	pic := strings.Repeat(`'HH:'HH `, 40) // over 256 bytes
+	if o, e := string(AppendSnap(nil, pic, 0)), strings.Repeat(`HH:00 `, 40); o != e {
+		t.Errorf("long pic is broken! o≢e >%s< ≢ >%s<", o, e)
+	}
+}
+
+func TestAllocs(t *testing.T) {
+	var line [64]byte
+	for _, v := range appendTests {
+		if n := testing.AllocsPerRun(100, func() {
+			AppendSnap(line[:0], v.pic, v.inp)
+			Len(v.pic, v.inp)
+		}); n != 0 {
+			t.Errorf("%s makes %v allocations", v.name, n)
+		}
+	}
+}
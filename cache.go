@@ -0,0 +1,215 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Pic cache. Readers load an immutable map, writers copy it under a lock.
+var cache struct {
+	m    atomic.Value // map[string]*prog, nil if Snap does not use cache
+	mu   sync.Mutex   // writers
+	max  int64        // atomic: entries allowed
+	hits uint64       // atomic
+	miss uint64       // atomic
+}
+
+// Func SetCache makes Snap keep up to n pics compiled, so pics passed again
+// and again, eg. literals in closures, are not parsed on each call. Once the
+// cache is full new pics are not added, so it suits a program's own, fixed
+// set of pics. SetCache(0) turns the cache off. Both drop cached pics and
+// zero stats. Cache is safe for concurrent use, reads take no locks.
+//
+// Compiled pics show the same as interpreted ones. Pics with blocks and
+// repeats are kept in the cache, but they run through the interpreter.
+func SetCache(n int) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	m := map[string]*prog(nil)
+	if n > 0 {
+		m = map[string]*prog{}
+	}
+	atomic.StoreInt64(&cache.max, int64(n))
+	atomic.StoreUint64(&cache.hits, 0)
+	atomic.StoreUint64(&cache.miss, 0)
+	cache.m.Store(m)
+}
+
+// Func CacheStats returns how many Snap calls found their pic in the cache
+// and how many did not, since the last SetCache.
+func CacheStats() (hits, misses uint64) {
+	return atomic.LoadUint64(&cache.hits), atomic.LoadUint64(&cache.miss)
+}
+
+// cached returns the compiled pic, compiling and storing it if there is
+// room. It returns nil for pics that the interpreter runs, and for misses
+// once the cache is full. Map m is the cache as Snap loaded it.
+func cached(m map[string]*prog, pic string) *prog {
+	if g, ok := m[pic]; ok {
+		atomic.AddUint64(&cache.hits, 1)
+		return g
+	}
+	atomic.AddUint64(&cache.miss, 1)
+	if int64(len(m)) >= atomic.LoadInt64(&cache.max) { // full, no compile nor lock
+		return nil
+	}
+	g := compile(pic)
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	m, _ = cache.m.Load().(map[string]*prog)
+	if _, ok := m[pic]; ok || int64(len(m)) >= atomic.LoadInt64(&cache.max) {
+		return g
+	}
+	nm := make(map[string]*prog, len(m)+1)
+	for k, v := range m {
+		nm[k] = v
+	}
+	nm[pic] = g
+	cache.m.Store(nm)
+	return g
+}
+
+// prog is a compiled pic: steps in the order Snap would take them.
+type prog struct {
+	ops []op
+}
+
+// op is a command or a run of text. Text is in output order.
+type op struct {
+	w    byte   // command, 0 for text
+	lab  bool   // text of a label, shown as the label command says
+	pi   int    // pic index of the command
+	text string // text to show
+	low  string // text of a lowercased label
+}
+
+// compile makes a prog of pic the way snap would parse it. It returns nil
+// for pics with blocks or repeats.
+func compile(pic string) *prog {
+	for i := 0; i < len(pic); i++ {
+		switch pic[i] {
+		case '[', ']', '{', '}', '|':
+			return nil
+		}
+	}
+	g := &prog{}
+	var run []byte // text, reversed
+	lab := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		t := make([]byte, len(run))
+		l := make([]byte, len(run))
+		for i, c := range run {
+			t[len(t)-1-i] = c
+			if lab && c > 63 && c < 91 {
+				c |= 0x20
+			}
+			l[len(l)-1-i] = c
+		}
+		g.ops = append(g.ops, op{lab: lab, text: string(t), low: string(l)})
+		run = run[:0]
+	}
+	text := func(c byte, inLabel bool) {
+		if inLabel != lab {
+			flush()
+			lab = inLabel
+		}
+		run = append(run, c)
+	}
+	var asis byte // 0: commands, 1: quoted, 2: label
+	for pi := len(pic); pi > 0; {
+		pi--
+		w := pic[pi]
+		switch {
+		case pi > 0 && pic[pi-1] == '\\':
+			switch w {
+			case 'n':
+				w = '\n'
+			case 't':
+				w = '\t'
+			}
+			text(w, false)
+			pi--
+			continue
+		case asis == 0:
+		case w == '\'':
+			asis = 0
+			continue
+		case asis == 1:
+			text(w, false)
+			continue
+		case w|3 == 63:
+			asis = 0
+		default:
+			text(w, true)
+			continue
+		}
+		o := op{w: w, pi: pi}
+		switch w {
+		case '\'':
+			asis = 1
+			continue
+		case '?', '=', '>', '<':
+			asis = 2
+		case 'H': // cmd takes the whole flock
+			for pi > 0 && pic[pi-1] == 'H' {
+				pi--
+			}
+		case 'B', 'E', 'F', 'G', 'A', 'C':
+		case '@':
+			// at tells where the command starts and how wide it is
+			var ok bool
+			b := make([]byte, len(pic))
+			if pi, _, _, _, ok = at(pic, pi, 0, b, len(b), nil); !ok {
+				pi = 0 // PICERR ends the pic
+			}
+		case 0: // snap drops it
+			continue
+		default:
+			text(w, false)
+			continue
+		}
+		flush()
+		g.ops = append(g.ops, o)
+	}
+	flush()
+	return g
+}
+
+// run shows from as pic, compiled to g, would show it.
+func (g *prog) run(pic string, from uint64) []byte {
+	ot := make([]byte, len(pic))
+	oi := len(ot)
+	var asis byte // of the last label
+	var n uint
+	var ok bool
+	for i := range g.ops {
+		o := &g.ops[i]
+		if o.w != 0 {
+			if _, n, asis, ot, oi, ok = cmd(pic, o.pi, from, asis, ot, oi, nil); !ok {
+				break
+			}
+			from >>= n
+			continue
+		}
+		t := o.text
+		switch {
+		case !o.lab || asis == 3:
+		case asis == 2:
+			t = o.low
+		default:
+			continue
+		}
+		oi -= len(t)
+		copy(ot[oi:], t)
+	}
+	return ot[oi:]
+}
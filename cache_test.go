@@ -0,0 +1,141 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func ExampleSetCache() {
+	SetCache(64)
+	defer SetCache(0)
+	for i := uint64(0); i < 3; i++ {
+		fmt.Printf("%s\n", Snap(`ACK=' Id:'HH`, i<<8|0x5a))
+	}
+	fmt.Println(CacheStats())
+
+	// Output:
+	// ack Id:5A
+	// ACK Id:5A
+	// ack Id:5A
+	// 2 1
+}
+
+// interp runs pic through the interpreter only.
+func interp(pic string, from uint64) string {
+	ot, oi := snap(pic, from, make([]byte, len(pic)), len(pic), nil)
+	return string(ot[oi:])
+}
+
+// cachePics are pics of other tests.
+func cachePics() []string {
+	var pics []string
+	for _, v := range parseTests {
+		pics = append(pics, v.pic)
+	}
+	for _, v := range xparseTests {
+		pics = append(pics, v.pic)
+	}
+	for _, v := range diffTests {
+		pics = append(pics, v.pic)
+	}
+	for _, v := range multiTests {
+		pics = append(pics, v.pic)
+	}
+	return pics
+}
+
+// randPic glues n random tokens into a pic.
+func randPic(r *rand.Rand, n int) string {
+	toks := []string{`B`, `E`, `F`, `H`, `HH`, `HHHH`, `G`, `A`, `C`, `?`,
+		`ACK=`, `on>`, `off<`, `'quo:'`, ` `, `:`, `.`, `\n`, `\t`, `\B`, `\'`,
+		`D.08@`, `!04@`, `Q0.16@`, `X.16@`, `IPv4.Address32@`, `Tu0.32@`,
+		`Yes?`, `x`, `Aa`, `no?`, `'`, `\`, `@`}
+	pic := ""
+	for i := 0; i < n; i++ {
+		pic += toks[r.Intn(len(toks))]
+	}
+	return pic
+}
+
+func TestCompile(t *testing.T) {
+	r := rand.New(rand.NewSource(46))
+	pics := cachePics()
+	for i := 0; i < 2000; i++ {
+		pics = append(pics, randPic(r, 1+r.Intn(12)))
+	}
+	fails := 0
+	for _, pic := range pics {
+		g := compile(pic)
+		if g == nil {
+			continue
+		}
+		for _, from := range []uint64{0, ^uint64(0), 0x5555555555555555, r.Uint64(), r.Uint64()} {
+			o, e := string(g.run(pic, from)), interp(pic, from)
+			if o != e && fails < 20 {
+				t.Logf("compiled %q of %#x is broken! o≢e\n>%s<\n>%s<", pic, from, o, e)
+				fails++
+			}
+		}
+	}
+	if fails != 0 {
+		t.Fail()
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	defer SetCache(0)
+	SetCache(2)
+	for _, pic := range []string{`B`, `E`, `B`, `F`, `F`, `B`, `[B]`, `[B]`} {
+		Snap(pic, 1)
+	}
+	if h, m := CacheStats(); h != 2 || m != 6 {
+		t.Errorf("full cache: hits %d misses %d, want 2 6", h, m)
+	}
+	if a := testing.AllocsPerRun(10, func() { Snap(`'x:'HH`, 1) }); a > 1 {
+		t.Errorf("full cache: miss makes %v allocs, want only output", a)
+	}
+	SetCache(0)
+	Snap(`B`, 1)
+	if h, m := CacheStats(); h != 0 || m != 0 {
+		t.Errorf("cache off: hits %d misses %d, want 0 0", h, m)
+	}
+}
+
+// go test -race -run=CacheParallel
+func TestCacheParallel(t *testing.T) {
+	defer SetCache(0)
+	SetCache(len(parseTests) / 2)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := range parseTests {
+				v := parseTests[(i+w*7)%len(parseTests)]
+				if o := string(Snap(v.pic, v.inp)); o != v.out {
+					t.Errorf("%s is broken! o≢e >%s< ≢ >%s<", v.name, o, v.out)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if h, m := CacheStats(); h+m != 8*uint64(len(parseTests)) {
+		t.Errorf("hits %d + misses %d is not %d", h, m, 8*len(parseTests))
+	}
+}
+
+func BenchmarkSnapCached(b *testing.B) {
+	SetCache(8)
+	defer SetCache(0)
+	for i := 0; i < b.N; i++ {
+		_ = Snap(`'PT:'F 'EXT=.ACK= Id:0xFHH!48@`, header)
+	}
+}
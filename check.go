@@ -0,0 +1,66 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package bitpeek
+
+import "github.com/ohir/bitpeek/internal/parse"
+
+// PicError tells what is wrong with a picstring and where.
+type PicError struct {
+	Pos int    // pic index the problem was found at
+	Err string // what is wrong
+}
+
+func (e *PicError) Error() string {
+	var b [20]byte
+	i, x := len(b), uint(e.Pos)
+	for {
+		i--
+		b[i] = byte(48 + x%10)
+		if x /= 10; x == 0 {
+			break
+		}
+	}
+	if e.Pos < 0 {
+		i--
+		b[i] = '-'
+	}
+	return "bitpeek: pic at " + string(b[i:]) + ": " + e.Err
+}
+
+// Func Check validates pic once, eg. in an init or a test, so errors that
+// Snap can only show as PICERR! are caught before any data flows. It also
+// checks that all variants of each switch block take the same number of
+// bits as the last variant does. A shorter variant declares its own width
+// with a !dd@ skip on its left:
+//    'T:'F[ ping:HH| len:D.05@ ch:E!01@| raw:HH]
+//
+// Returned error, if any, is a *PicError.
+func Check(pic string) error {
+	p := &peek{chk: true}
+	snap(pic, 0, make([]byte, len(pic)), len(pic), p)
+	if p.err != nil {
+		return p.err
+	}
+	return nil
+}
+
+// variants checks widths of all variants of the switch block b whose last
+// variant took b.w bits.
+func (p *peek) variants(pic string, b *blk) {
+	for s := uint64(0); p.err == nil; s++ {
+		l, r := parse.Variant(pic[:b.k], b.j, s)
+		if r < 0 {
+			return
+		}
+		v := &peek{chk: true}
+		snap(pic[l+1:r], 0, make([]byte, r-l-1), r-l-1, v)
+		switch {
+		case v.err != nil:
+			p.err = &PicError{v.err.Pos + l + 1, v.err.Err}
+		case v.nb != b.w:
+			p.err = &PicError{l + 1, "variant width differs from the last one"}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package bitpeek
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExampleCheck() {
+	// Low 4 bits of ping variant are not covered
+	err := Check(`'T:'F[ ping:H| len:D.05@ ch:E!01@| raw:HH]`)
+	fmt.Println(err)
+
+	// Output:
+	// bitpeek: pic at 6: variant width differs from the last one
+}
+
+var checkTests = []struct {
+	name string
+	pic  string
+	pos  int // -1 for a good pic
+}{
+	//bitpeek:check:1
+	{`plain`, `'Type:'F 'EXT=.ACK= Id:0xFHH from IPv4.Address32@:D.16@`, -1},
+	//bitpeek:check:1
+	{`switch`, `'T:'F[ ping:HH| len:D.05@ ch:E!01@| raw:HH]`, -1},
+	//bitpeek:check:1
+	{`one variant`, `B[HH]`, -1},
+	//bitpeek:check:1
+	{`empty variants`, `B[a|b]`, -1},
+	//bitpeek:check:1
+	{`bad bitcount`, `HH D.99@`, 7},
+	//bitpeek:check:1
+	{`unknown @`, `HH Y.12@`, 7},
+	//bitpeek:check:1
+	{`quoted @`, `'Y.12@'`, -1},
+	//bitpeek:check:1
+	{`wide first`, `E[HHH|HH]`, 2},
+	//bitpeek:check:1
+	{`narrow mid`, `E[HH|H|HH]`, 5},
+	//bitpeek:check:1
+	{`bad in variant`, `E[D.77@|!12@]`, 6},
+	//bitpeek:check:1
+	{`nested good`, `E[ B[a|b]H| c:H!01@]`, -1},
+	//bitpeek:check:1
+	{`nested bad`, `E[ B[a|bH]H| c:H!01@]`, 5},
+}
+
+func TestCheck(t *testing.T) {
+	for _, v := range checkTests {
+		err := Check(v.pic)
+		switch e, _ := err.(*PicError); {
+		case err == nil && v.pos < 0:
+		case err == nil:
+			t.Errorf("%s: no error, want one at %d", v.name, v.pos)
+		case e == nil:
+			t.Errorf("%s: error is not a *PicError: %v", v.name, err)
+		case e.Pos != v.pos:
+			t.Errorf("%s: %v, want error at %d", v.name, err, v.pos)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+// Command bitpeek-pcap prints packets of a classic libpcap file through
+// bitpeek preset pics, one line per protocol layer:
+//
+//	$ bitpeek-pcap -n 1 testdata/sample.pcap
+//	1 2018-03-04T05:06:07.000123Z 71/71
+//	  ETH dst:00:1a:2b:3c:4d:5e src:52:54:00:12:34:56 type:0x0800
+//	  IPv4 ver:4 ihl:5 dscp:0 ecn:0 len:57 id:0x1C46 DF frag:0 ttl:64 ...
+//	  UDP sport:41234 dport:53 len:37 sum:0x1C2F
+//
+// First line gives the packet number, its time and captured/original length.
+// Layers are the dissect ones: Ethernet frames are followed to IPv4 by its
+// EtherType, IPv4 to TCP, UDP or ICMP by its proto field, unless it is a
+// fragment past the first. Headers cut short by snaplen show as far as data
+// goes, missing bytes read as zeros. Link types read are Ethernet (1), raw
+// IP (101) and raw IPv4 (228). Raw IP packets of other versions only get
+// a line that names it. Pcapng files are not read.
+//
+// Usage:
+//
+//	bitpeek-pcap [-n count] file.pcap
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ohir/bitpeek"
+	"github.com/ohir/bitpeek/dissect"
+)
+
+func main() {
+	n := flag.Int("n", 0, "print only the first `count` packets")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: bitpeek-pcap [-n count] file.pcap\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	f, err := os.Open(flag.Arg(0))
+	if err == nil {
+		err = run(os.Stdout, f, *n)
+		f.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bitpeek-pcap: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run prints up to n packets, all if n is 0, read from r to w.
+func run(w io.Writer, r io.Reader, n int) error {
+	var gh [24]byte
+	if _, err := io.ReadFull(r, gh[:]); err != nil {
+		return fmt.Errorf("no pcap header: %v", err)
+	}
+	var bo binary.ByteOrder = binary.LittleEndian
+	if gh[0] == 0xa1 {
+		bo = binary.BigEndian
+	}
+	nano := false
+	switch bo.Uint32(gh[0:]) {
+	case 0xa1b2c3d4:
+	case 0xa1b23c4d:
+		nano = true
+	default:
+		return errors.New("not a pcap file")
+	}
+	link := bo.Uint32(gh[20:]) & 0xffff
+	switch link {
+	case 1, 101, 228:
+	default:
+		return fmt.Errorf("link type %d is not read", link)
+	}
+	ts := `Tu0.64@` // µs since the Unix epoch
+	if nano {
+		ts = `Tn0.64@`
+	}
+	var ph [16]byte
+	var data []byte
+	for i := 1; n == 0 || i <= n; i++ {
+		if _, err := io.ReadFull(r, ph[:]); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("packet %d: %v", i, err)
+		}
+		incl, orig := bo.Uint32(ph[8:]), bo.Uint32(ph[12:])
+		if incl > 1<<18 {
+			return fmt.Errorf("packet %d: length %d is too big", i, incl)
+		}
+		if cap(data) < int(incl) {
+			data = make([]byte, incl)
+		}
+		data = data[:incl]
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("packet %d: %v", i, err)
+		}
+		sub := uint64(bo.Uint32(ph[4:]))
+		if nano {
+			sub += uint64(bo.Uint32(ph[0:])) * 1e9
+		} else {
+			sub += uint64(bo.Uint32(ph[0:])) * 1e6
+		}
+		fmt.Fprintf(w, "%d %s %d/%d\n", i, bitpeek.Snap(ts, sub), incl, orig)
+		for _, l := range layers(link, data) {
+			fmt.Fprintf(w, "  %s\n", l)
+		}
+	}
+	return nil
+}
+
+// layers returns lines of headers found in data of link type.
+func layers(link uint32, data []byte) [][]byte {
+	var out [][]byte
+	if link == 101 && (len(data) == 0 || data[0]>>4 != 4) { // may be IPv6
+		var v uint64
+		if len(data) > 0 {
+			v = uint64(data[0] >> 4)
+		}
+		return append(out, bitpeek.Snap(`'IPv'D.04@' is not read'`, v))
+	}
+	root := dissect.IPv4
+	if link == 1 {
+		root = dissect.Ethernet
+	}
+	parts, _ := dissect.Dissect(root, data) // errors are of bad layers only
+	for _, p := range parts {
+		out = append(out, p.Line)
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.txt")
+
+// TestRun prints testdata captures and compares with their .txt files.
+func TestRun(t *testing.T) {
+	for _, name := range []string{"sample", "raw-ns"} {
+		f, err := os.Open("testdata/" + name + ".pcap")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		err = run(&b, f, 0)
+		f.Close()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if *update {
+			if err = os.WriteFile("testdata/"+name+".txt", b.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile("testdata/" + name + ".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != string(want) {
+			t.Errorf("%s: o≢e\n%s≢\n%s", name, b.String(), want)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	sample, err := os.ReadFile("testdata/sample.pcap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := append([]byte(nil), sample[:24]...)
+	link[20] = 113 // Linux cooked
+	for _, v := range []struct {
+		name string
+		in   []byte
+		err  string
+	}{
+		{`empty`, nil, `no pcap header`},
+		{`pcapng`, append([]byte{0x0a, 0x0d, 0x0d, 0x0a}, sample[4:24]...), `not a pcap file`},
+		{`link`, link, `link type 113`},
+		{`cut record`, sample[:30], `packet 1: unexpected EOF`},
+		{`cut data`, sample[:50], `packet 1: unexpected EOF`},
+	} {
+		err := run(&bytes.Buffer{}, bytes.NewReader(v.in), 0)
+		if err == nil || !strings.Contains(err.Error(), v.err) {
+			t.Errorf("%s: got %v, want %s", v.name, err, v.err)
+		}
+	}
+	var b bytes.Buffer
+	if err := run(&b, bytes.NewReader(sample), 2); err != nil || strings.Count(b.String(), "\n") != 8 {
+		t.Errorf("-n 2 gave %v\n%s", err, b.String())
+	}
+}
+
+// TestRawIPv6 checks that raw IP packets that are not IPv4 are only named.
+func TestRawIPv6(t *testing.T) {
+	pcap := []byte{0xd4, 0xc3, 0xb2, 0xa1, 2, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 4, 0, 101, 0, 0, 0}
+	pcap = append(pcap, 0, 0, 0, 0, 0, 0, 0, 0, 40, 0, 0, 0, 40, 0, 0, 0)
+	pcap = append(pcap, 0x60, 0, 0, 0, 0, 0, 17, 64)
+	pcap = append(pcap, make([]byte, 32)...)
+	var b bytes.Buffer
+	if err := run(&b, bytes.NewReader(pcap), 0); err != nil {
+		t.Fatal(err)
+	}
+	if o, e := b.String(), "1 1970-01-01T00:00:00.000000Z 40/40\n  IPv6 is not read\n"; o != e {
+		t.Errorf("o≢e\n%s≢\n%s", o, e)
+	}
+}
@@ -0,0 +1,408 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ohir/bitpeek/internal/parse"
+)
+
+// op is a piece of output. Ops are made right to left, same as Snap makes
+// its output, then emitted left to right.
+type op struct {
+	kind byte   // 'L'iteral, label: = > <, command: ? B E F H G A C D I b X x O *, block: [ ] S
+	text string // literal or label text
+	bit  uint   // lowest bit taken
+	n    uint   // bits taken
+	alt  [][]op // switch variants
+}
+
+// compile walks pic exactly as bitpeek.Snap does. Where Snap would output
+// a byte, compile records what it would be made of.
+func compile(pic string) ([]op, error) {
+	ops, _, err := compileIn(pic, 0)
+	return ops, err
+}
+
+// compileIn compiles pic that is nested in bn blocks. It returns also the
+// number of bits pic takes.
+func compileIn(pic string, bn int) ([]op, uint, error) {
+	var ops []op
+	var at uint   // bits taken so far
+	var asis byte // same flow states as in Snap
+	var lbl byte  // kind of label text being read
+	var bs [4]int // open blocks
+	top := bn
+	var rj, rn, rg, rd int // repeat state as in Snap
+	var rs byte
+	put := func(kind byte, c byte) { // prepend c to the rightmost op
+		if n := len(ops) - 1; n >= 0 && ops[n].kind == kind && ops[n].n == 0 &&
+			(kind == 'L' || ops[n].bit == at-1) {
+			ops[n].text = string([]byte{c}) + ops[n].text
+			return
+		}
+		ops = append(ops, op{kind: kind, text: string([]byte{c}), bit: at - 1})
+	}
+	cmd := func(kind byte, n uint) {
+		ops = append(ops, op{kind: kind, bit: at, n: n})
+		at += n
+	}
+	pi := len(pic)
+	for pi > 0 {
+		pi--
+		w := pic[pi]
+		switch { // labels and escapes
+		case pi > 0 && pic[pi-1] == '\\':
+			switch w {
+			case 'n':
+				w = '\n'
+			case 't':
+				w = '\t'
+			}
+			put('L', w)
+			pi--
+			continue
+		case w == '}' && asis == 0 && rn == 0:
+			if rj, rn, rg, rs = parse.Repeat(pic[:pi]); rn > 0 {
+				rd, pi = 0, rj
+				continue
+			}
+		case w == ']' && asis != 1 && bn < len(bs):
+			j, _, sw := parse.Block(pic[:pi])
+			switch {
+			case j > 0 && sw > 0:
+				o, err := variants(pic[:pi], j, bn+1, at)
+				if err != nil {
+					return nil, 0, err
+				}
+				o.n = sw
+				ops = append(ops, o)
+				at = o.bit
+				pi = j
+				asis = 0
+				continue
+			case j > 0:
+				bs[bn] = j
+				bn++
+				ops = append(ops, op{kind: ']'})
+				asis = 0
+				continue
+			}
+		case bn > top && pi == bs[bn-1]:
+			bn--
+			ops = append(ops, op{kind: '[', text: pic[pi-1 : pi], bit: at})
+			asis = 0
+			continue
+		}
+		switch {
+		case asis == 0:
+		case w == '\'':
+			asis = 0
+			continue
+		case asis == 1:
+			put('L', w)
+			continue
+		case w|3 == 63:
+			asis = 0
+		default: // label text
+			put(lbl, w)
+			continue
+		}
+		switch w {
+		case '\'':
+			asis = 1
+		case '?':
+			cmd('?', 1)
+			asis, lbl = 3, 'L'
+		case '=', '>', '<':
+			at++
+			asis, lbl = 2, w
+		case 'B':
+			cmd('B', 1)
+		case 'E':
+			cmd('E', 2)
+		case 'F':
+			cmd('F', 3)
+		case 'G':
+			cmd('G', 5)
+		case 'A':
+			cmd('A', 7)
+		case 'C':
+			cmd('C', 8)
+		case 'H':
+			n := uint(4)
+			for pi > 0 && pic[pi-1] == 'H' {
+				pi--
+				n += 4
+			}
+			cmd('H', n)
+		case '@':
+			if pi < 2 {
+				return nil, 0, errors.New("@ needs two digits before it")
+			}
+			k := (10 * uint8(pic[pi-2]-48)) + uint8(pic[pi-1]-48)
+			d := 4
+			if k > 16 {
+				d = int(k / 3)
+			}
+			switch {
+			case k == 0, k > 64:
+				return nil, 0, fmt.Errorf("bad bitcount %q at %d", pic[pi-2:pi+1], pi-2)
+			case pi > 2 && pic[pi-3] == '!':
+				pi -= 3
+				at += uint(k)
+			case pi > 3 && pic[pi-4] == 'B':
+				cmd('b', uint(k))
+				if s := pic[pi-3]; s != '.' {
+					ops[len(ops)-1].text = string([]byte{s})
+				}
+				pi -= 4
+			case pi > 6 && (k == 16 || k == 32) && pic[pi-7:pi-2] == "Float":
+				cmd('*', uint(k))
+				ops[len(ops)-1].text = pic[pi-7 : pi+1]
+				pi -= 7
+			case pi > 4 && pic[pi-5] == 'G' && pic[pi-4]-48 < 10 && pic[pi-3] == '.':
+				cmd('*', uint(k))
+				ops[len(ops)-1].text = pic[pi-5 : pi+1]
+				pi -= 5
+			case pi > 5 && pic[pi-6] == 'T' && strings.IndexByte("smun", pic[pi-5]) >= 0 &&
+				pic[pi-4]-48 < 10 && pic[pi-3] == '.':
+				cmd('*', uint(k))
+				ops[len(ops)-1].text = pic[pi-6 : pi+1]
+				pi -= 6
+			case pi > 4 && pic[pi-5] == 'P' && strings.IndexByte("smun", pic[pi-4]) >= 0 &&
+				pic[pi-3] == '.':
+				cmd('*', uint(k))
+				ops[len(ops)-1].text = pic[pi-5 : pi+1]
+				pi -= 5
+			case pi > 7 && (pic[pi-8] == 'Q' || pic[pi-8] == 'U') &&
+				pic[pi-5] == '.' && pic[pi-3] == '.' && pic[pi-4]-48 < 10 &&
+				pic[pi-7]-48 < 10 && pic[pi-6]-48 < 10 &&
+				10*(pic[pi-7]-48)+pic[pi-6]-48 <= k:
+				cmd('*', uint(k))
+				ops[len(ops)-1].text = pic[pi-8 : pi+1]
+				pi -= 8
+			case pi > 3 && (pic[pi-3] == '.' || pic[pi-3] == '0') &&
+				(pic[pi-4] == 'X' || pic[pi-4] == 'x' || pic[pi-4] == 'O'):
+				cmd(pic[pi-4], uint(k))
+				if pic[pi-3] == '0' {
+					ops[len(ops)-1].text = "0"
+				}
+				pi -= 4
+			case pi > d-1 && pic[pi-d] == 'D':
+				pi -= d
+				cmd('D', uint(k))
+			case pi > 13 && pic[pi-14] == 'I':
+				pi -= 14
+				cmd('I', 32)
+			default:
+				return nil, 0, fmt.Errorf("unknown @ command ending at %d", pi)
+			}
+		case 0: // Snap never emits NUL
+		default:
+			put('L', w)
+		}
+		if rn > 0 && pi < rj {
+			if rd++; rd == rn {
+				rn = 0
+				continue
+			}
+			if rg > 0 && rd%rg == 0 {
+				put('L', rs)
+			}
+			pi = rj
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops, at, nil
+}
+
+// variants compiles all variants of the switch block opened at j and closed
+// right after pic. Block starts at bit at. Returned op has its bit set to
+// where the selector starts.
+func variants(pic string, j, bn int, at uint) (op, error) {
+	o := op{kind: 'S'}
+	var w []uint
+	for s := uint64(0); ; s++ {
+		l, r := parse.Variant(pic, j, s)
+		if r < 0 {
+			break
+		}
+		ops, n, err := compileIn(pic[l+1:r], bn)
+		if err != nil {
+			return o, fmt.Errorf("variant %d: %v", s, err)
+		}
+		shift(ops, at)
+		o.alt = append(o.alt, ops)
+		w = append(w, n)
+	}
+	last := w[len(w)-1]
+	for s, n := range w {
+		if n != last {
+			return o, fmt.Errorf("variant %d takes %d bits, last one takes %d", s, n, last)
+		}
+	}
+	o.bit = at + last
+	return o, nil
+}
+
+// shift moves ops, with their variants, up by at bits.
+func shift(ops []op, at uint) {
+	for i := range ops {
+		ops[i].bit += at
+		for _, a := range ops[i].alt {
+			shift(a, at)
+		}
+	}
+}
+
+// field returns Go expression for n bits of v from bit up.
+func field(bit, n uint) string {
+	if bit >= 64 {
+		return "0"
+	}
+	x := "v"
+	if bit > 0 {
+		x = fmt.Sprintf("v>>%d", bit)
+	}
+	if n >= 64-bit {
+		return x
+	}
+	return fmt.Sprintf("%s&%#x", x, uint64(1)<<n-1)
+}
+
+// cond returns comparison with 0 that shows > and < labels.
+func cond(k byte) string {
+	if k == '<' {
+		return "=="
+	}
+	return "!="
+}
+
+// lower is Snap's lowercasing of labels.
+func lower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c > 63 && c < 91 {
+			b[i] = c | 0x20
+		}
+	}
+	return string(b)
+}
+
+// emit writes statements appending ops to dst.
+func emit(b *strings.Builder, ops []op) {
+	app := func(ind, s string) {
+		fmt.Fprintf(b, "%sdst = append(dst, %s...)\n", ind, strconv.Quote(s))
+	}
+	dec := func(x string) {
+		fmt.Fprintf(b, "\t{\n\t\tvar d [20]byte\n\t\ti, x := len(d)-1, uint64(%s)\n", x)
+		b.WriteString("\t\tfor ; x > 9; i-- {\n\t\t\td[i] = byte('0' + x%10)\n\t\t\tx /= 10\n\t\t}\n")
+		b.WriteString("\t\td[i] = byte('0' + x)\n\t\tdst = append(dst, d[i:]...)\n\t}\n")
+	}
+	for _, o := range ops {
+		switch o.kind {
+		case 'L':
+			app("\t", o.text)
+		case '=':
+			fmt.Fprintf(b, "\tif %s != 0 {\n", field(o.bit, 1))
+			app("\t\t", o.text)
+			b.WriteString("\t} else {\n")
+			app("\t\t", lower(o.text))
+			b.WriteString("\t}\n")
+		case '>', '<':
+			fmt.Fprintf(b, "\tif %s %s 0 {\n", field(o.bit, 1), cond(o.kind))
+			app("\t\t", o.text)
+			b.WriteString("\t}\n")
+		case '[':
+			fmt.Fprintf(b, "\tif %s %s 0 {\n", field(o.bit, 1), cond(o.text[0]))
+		case 'S':
+			fmt.Fprintf(b, "\tswitch %s {\n", field(o.bit, o.n))
+			for s, a := range o.alt {
+				fmt.Fprintf(b, "\tcase %d:\n", s)
+				emit(b, a)
+			}
+			b.WriteString("\t}\n")
+		case ']':
+			b.WriteString("\t}\n")
+		case '?', 'B', 'E', 'F':
+			fmt.Fprintf(b, "\tdst = append(dst, '0'+byte(%s))\n", field(o.bit, o.n))
+		case 'H':
+			for s := o.bit + o.n; s > o.bit; s -= 4 {
+				fmt.Fprintf(b, "\tdst = append(dst, \"0123456789ABCDEF\"[%s])\n", field(s-4, 4))
+			}
+		case 'G':
+			fmt.Fprintf(b, "\tdst = append(dst, \"abcdefghijklmnopqrstuvwxyz234567\"[%s])\n",
+				field(o.bit, 5))
+		case 'A', 'C': // unprintable ones are shown as bitpeek.Escape tells
+			fmt.Fprintf(b, "\tif c := byte(%s); c < 32 ||\n\t\tbitpeek.Escape != bitpeek.EscTilde && c > 126 && c < 160 {\n", field(o.bit, o.n))
+			fmt.Fprintf(b, "\t\tdst = bitpeek.AppendSnap(dst, \"%c\", uint64(c))\n\t} else {\n", o.kind)
+			b.WriteString("\t\tdst = append(dst, c)\n\t}\n")
+		case 'b':
+			for i := o.n; i > 0; i-- {
+				fmt.Fprintf(b, "\tdst = append(dst, '0'+byte(%s))\n", field(o.bit+i-1, 1))
+				if o.text != "" && i > 1 && (i-1)%4 == 0 {
+					app("\t", o.text)
+				}
+			}
+		case 'X', 'x', 'O':
+			tbl, s := "0123456789ABCDEF", uint(4)
+			switch o.kind {
+			case 'x':
+				tbl = "0123456789abcdef"
+			case 'O':
+				tbl, s = "01234567", 3
+			}
+			if o.text == "0" { // padded
+				for i := (o.n + s - 1) / s; i > 0; i-- {
+					w := s
+					if i*s > o.n {
+						w = o.n - (i-1)*s
+					}
+					fmt.Fprintf(b, "\tdst = append(dst, %q[%s])\n", tbl, field(o.bit+(i-1)*s, w))
+				}
+				break
+			}
+			fmt.Fprintf(b, "\t{\n\t\tvar d [22]byte\n\t\ti, x := len(d)-1, uint64(%s)\n", field(o.bit, o.n))
+			fmt.Fprintf(b, "\t\tfor ; x > %d; i-- {\n\t\t\td[i] = %q[x&%d]\n\t\t\tx >>= %d\n\t\t}\n",
+				len(tbl)-1, tbl, len(tbl)-1, s)
+			fmt.Fprintf(b, "\t\td[i] = %q[x]\n\t\tdst = append(dst, d[i:]...)\n\t}\n", tbl)
+		case '*': // Float, fixed point, Time, Period, Alphabet: left to the interpreter
+			fmt.Fprintf(b, "\tdst = bitpeek.AppendSnap(dst, %q, %s)\n",
+				o.text, field(o.bit, o.n))
+		case 'D':
+			dec(field(o.bit, o.n))
+		case 'I':
+			for s := o.bit + 24; ; s -= 8 {
+				dec(field(s, 8))
+				if s == o.bit {
+					break
+				}
+				app("\t", ".")
+			}
+		}
+	}
+}
+
+// uses tells whether any of ops, or of their variants, is of one of kinds.
+func uses(ops []op, kinds string) bool {
+	for _, o := range ops {
+		if strings.IndexByte(kinds, o.kind) >= 0 {
+			return true
+		}
+		for _, a := range o.alt {
+			if uses(a, kinds) {
+				return true
+			}
+		}
+	}
+	return false
+}
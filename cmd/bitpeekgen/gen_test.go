@@ -0,0 +1,81 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestCamel(t *testing.T) {
+	for _, v := range []struct{ in, out string }{
+		{`header`, `Header`},
+		{`ext head`, `ExtHead`},
+		{`decimal big`, `DecimalBig`},
+		{`bitlabel escapes`, `BitlabelEscapes`},
+		{`v2-frame.ID`, `V2FrameID`},
+	} {
+		if o := camel(v.in); o != v.out {
+			t.Errorf("camel(%q): got %q want %q", v.in, o, v.out)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, pic := range []string{
+		`@`,
+		`5@`,
+		`H!00@`,
+		`badH!65@`,
+		`D. 16@`,
+		`IPv4.Addres32@`,
+		`E[HHH|HH]`,
+		`E[D.99@|HH]`,
+	} {
+		if _, err := compile(pic); err == nil {
+			t.Errorf("compile(%q) gave no error", pic)
+		}
+	}
+}
+
+func TestCompileOps(t *testing.T) {
+	ops, err := compile(`'Type:'F 'EXT=.ACK< Id:0xFHH!48@`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []op{
+		{kind: 'L', text: `Type:`},
+		{kind: 'F', bit: 61, n: 3},
+		{kind: 'L', text: ` `},
+		{kind: '=', text: `EXT`, bit: 60},
+		{kind: '<', text: `.ACK`, bit: 59},
+		{kind: 'L', text: ` Id:0x`},
+		{kind: 'F', bit: 56, n: 3},
+		{kind: 'H', bit: 48, n: 8},
+	}
+	if len(ops) != len(want) {
+		t.Fatalf("got %d ops want %d: %v", len(ops), len(want), ops)
+	}
+	for i := range want {
+		o := ops[i]
+		if o.kind == 'L' {
+			o.bit = 0
+		}
+		if w := want[i]; o.kind != w.kind || o.text != w.text || o.bit != w.bit || o.n != w.n {
+			t.Errorf("op %d: got %+v want %+v", i, o, want[i])
+		}
+	}
+}
+
+func TestCompileSwitch(t *testing.T) {
+	ops, err := compile(`'T:'F[ ping:HH| E[a|b]!06@]D.04@`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 4 || ops[2].kind != 'S' || ops[2].bit != 12 || ops[2].n != 3 {
+		t.Fatalf("bad switch op: %+v", ops)
+	}
+	if a := ops[2].alt; len(a) != 2 || len(a[0]) != 2 || a[0][1].bit != 4 ||
+		len(a[1]) != 3 || a[1][2].kind != 'S' || a[1][2].bit != 10 {
+		t.Errorf("bad variants: %+v", a)
+	}
+}
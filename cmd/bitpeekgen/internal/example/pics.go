@@ -0,0 +1,54 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package example keeps bitpeekgen output for a few picstrings so generated
+// code is compiled and tested along with the rest of the tree.
+package example
+
+//go:generate go run github.com/ohir/bitpeek/cmd/bitpeekgen pics.go
+
+//bitpeek:header
+const headerPic = `'Type:'F 'EXT=.ACK= Id:0xFHH from IPv4.Address32@:D.16@`
+
+var pics = [...]string{
+	//bitpeek:short
+	`'PT:'F 'EXT=.ACK= Id:0xFHH!48@`,
+	//bitpeek:hex64
+	`HHHHHHHHHHHHHHHH`,
+	//bitpeek:indicators
+	`'TX= RX> AK< ER? ` + "\n",
+	//bitpeek:chars
+	`'Ascii:' A 'Char:' C 'C32s:' GG 'Octal:' 0EFF 'Bit:' B`,
+	//bitpeek:big dec
+	`D64................64@`,
+	//bitpeek:escapes
+	`偩 \=\<\'\>\?\A\B\C\D\t_Tab\n NewLine: \\backslash 'Lo\n=Up?`,
+}
+
+//bitpeek:ext block
+const extPic = `'Type:'F 'EXT>[ Id:0xFHH 'ACK=]' RDY<[ not ready] D.10@`
+
+//bitpeek:tagged union
+const unionPic = `'T:'F[ ping:HH| len:D.05@ ch:E!01@| 'ACK=.NAK=!06@| kind:E[ack|nak|rst]!06@| raw:HH]`
+
+//bitpeek:repeats
+const repeatPic = `'bits:'B{16/4_} 'mac:'HH{6/1:}`
+
+//bitpeek:binary
+const binaryPic = `'reg:'B_20@ 'lo:'B.06@ 'x:'B:04@{3/1 }`
+
+//bitpeek:hex oct
+const hexOctPic = `'id:0x'X.10@ 'lo:'x012@ 'oct:'O.09@ 'pad:'O010@ 'top:'x.20@`
+
+//bitpeek:fixed
+const fixedPic = `'T:'Q08.2.16@'C V:'U04.3.12@ 'avg:'Q32.9.36@`
+
+//bitpeek:float
+const floatPic = `'h:'Float16@' s:'Float32@`
+
+//bitpeek:time
+const timePic = `'at 'Tm0.42@' took 'Pm.22@`
+
+//bitpeek:ids
+const idsPic = `'id:'G2.40@ 'tok:'G4.24@`
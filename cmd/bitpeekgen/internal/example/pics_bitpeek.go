@@ -0,0 +1,506 @@
+// Code generated by bitpeekgen. DO NOT EDIT.
+
+package example
+
+import "github.com/ohir/bitpeek"
+
+// SnapHeader appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'Type:'F 'EXT=.ACK= Id:0xFHH from IPv4.Address32@:D.16@
+func SnapHeader(v uint64, dst []byte) []byte {
+	dst = append(dst, "Type:"...)
+	dst = append(dst, '0'+byte(v>>61))
+	dst = append(dst, " "...)
+	if v>>60&0x1 != 0 {
+		dst = append(dst, "EXT"...)
+	} else {
+		dst = append(dst, "ext"...)
+	}
+	if v>>59&0x1 != 0 {
+		dst = append(dst, ".ACK"...)
+	} else {
+		dst = append(dst, ".ack"...)
+	}
+	dst = append(dst, " Id:0x"...)
+	dst = append(dst, '0'+byte(v>>56&0x7))
+	dst = append(dst, "0123456789ABCDEF"[v>>52&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>48&0xf])
+	dst = append(dst, " from "...)
+	{
+		var d [20]byte
+		i, x := len(d)-1, uint64(v>>40&0xff)
+		for ; x > 9; i-- {
+			d[i] = byte('0' + x%10)
+			x /= 10
+		}
+		d[i] = byte('0' + x)
+		dst = append(dst, d[i:]...)
+	}
+	dst = append(dst, "."...)
+	{
+		var d [20]byte
+		i, x := len(d)-1, uint64(v>>32&0xff)
+		for ; x > 9; i-- {
+			d[i] = byte('0' + x%10)
+			x /= 10
+		}
+		d[i] = byte('0' + x)
+		dst = append(dst, d[i:]...)
+	}
+	dst = append(dst, "."...)
+	{
+		var d [20]byte
+		i, x := len(d)-1, uint64(v>>24&0xff)
+		for ; x > 9; i-- {
+			d[i] = byte('0' + x%10)
+			x /= 10
+		}
+		d[i] = byte('0' + x)
+		dst = append(dst, d[i:]...)
+	}
+	dst = append(dst, "."...)
+	{
+		var d [20]byte
+		i, x := len(d)-1, uint64(v>>16&0xff)
+		for ; x > 9; i-- {
+			d[i] = byte('0' + x%10)
+			x /= 10
+		}
+		d[i] = byte('0' + x)
+		dst = append(dst, d[i:]...)
+	}
+	dst = append(dst, ":"...)
+	{
+		var d [20]byte
+		i, x := len(d)-1, uint64(v&0xffff)
+		for ; x > 9; i-- {
+			d[i] = byte('0' + x%10)
+			x /= 10
+		}
+		d[i] = byte('0' + x)
+		dst = append(dst, d[i:]...)
+	}
+	return dst
+}
+
+// SnapShort appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'PT:'F 'EXT=.ACK= Id:0xFHH!48@
+func SnapShort(v uint64, dst []byte) []byte {
+	dst = append(dst, "PT:"...)
+	dst = append(dst, '0'+byte(v>>61))
+	dst = append(dst, " "...)
+	if v>>60&0x1 != 0 {
+		dst = append(dst, "EXT"...)
+	} else {
+		dst = append(dst, "ext"...)
+	}
+	if v>>59&0x1 != 0 {
+		dst = append(dst, ".ACK"...)
+	} else {
+		dst = append(dst, ".ack"...)
+	}
+	dst = append(dst, " Id:0x"...)
+	dst = append(dst, '0'+byte(v>>56&0x7))
+	dst = append(dst, "0123456789ABCDEF"[v>>52&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>48&0xf])
+	return dst
+}
+
+// SnapHex64 appends what bitpeek.Snap would make of v with a picstring:
+//
+//	HHHHHHHHHHHHHHHH
+func SnapHex64(v uint64, dst []byte) []byte {
+	dst = append(dst, "0123456789ABCDEF"[v>>60])
+	dst = append(dst, "0123456789ABCDEF"[v>>56&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>52&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>48&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>44&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>40&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>36&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>32&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>28&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>24&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>20&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>16&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>12&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>8&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>4&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v&0xf])
+	return dst
+}
+
+// SnapIndicators appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'TX= RX> AK< ER?
+func SnapIndicators(v uint64, dst []byte) []byte {
+	if v>>3&0x1 != 0 {
+		dst = append(dst, "TX"...)
+	} else {
+		dst = append(dst, "tx"...)
+	}
+	if v>>2&0x1 != 0 {
+		dst = append(dst, " RX"...)
+	}
+	if v>>1&0x1 == 0 {
+		dst = append(dst, " AK"...)
+	}
+	dst = append(dst, " ER"...)
+	dst = append(dst, '0'+byte(v&0x1))
+	dst = append(dst, " "...)
+	return dst
+}
+
+// SnapChars appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'Ascii:' A 'Char:' C 'C32s:' GG 'Octal:' 0EFF 'Bit:' B
+func SnapChars(v uint64, dst []byte) []byte {
+	dst = append(dst, "Ascii: "...)
+	if c := byte(v >> 27 & 0x7f); c < 32 ||
+		bitpeek.Escape != bitpeek.EscTilde && c > 126 && c < 160 {
+		dst = bitpeek.AppendSnap(dst, "A", uint64(c))
+	} else {
+		dst = append(dst, c)
+	}
+	dst = append(dst, " Char: "...)
+	if c := byte(v >> 19 & 0xff); c < 32 ||
+		bitpeek.Escape != bitpeek.EscTilde && c > 126 && c < 160 {
+		dst = bitpeek.AppendSnap(dst, "C", uint64(c))
+	} else {
+		dst = append(dst, c)
+	}
+	dst = append(dst, " C32s: "...)
+	dst = append(dst, "abcdefghijklmnopqrstuvwxyz234567"[v>>14&0x1f])
+	dst = append(dst, "abcdefghijklmnopqrstuvwxyz234567"[v>>9&0x1f])
+	dst = append(dst, " Octal: 0"...)
+	dst = append(dst, '0'+byte(v>>7&0x3))
+	dst = append(dst, '0'+byte(v>>4&0x7))
+	dst = append(dst, '0'+byte(v>>1&0x7))
+	dst = append(dst, " Bit: "...)
+	dst = append(dst, '0'+byte(v&0x1))
+	return dst
+}
+
+// SnapBigDec appends what bitpeek.Snap would make of v with a picstring:
+//
+//	D64................64@
+func SnapBigDec(v uint64, dst []byte) []byte {
+	{
+		var d [20]byte
+		i, x := len(d)-1, uint64(v)
+		for ; x > 9; i-- {
+			d[i] = byte('0' + x%10)
+			x /= 10
+		}
+		d[i] = byte('0' + x)
+		dst = append(dst, d[i:]...)
+	}
+	return dst
+}
+
+// SnapEscapes appends what bitpeek.Snap would make of v with a picstring:
+//
+//	偩 \=\<\'\>\?\A\B\C\D\t_Tab\n NewLine: \\backslash 'Lo\n=Up?
+func SnapEscapes(v uint64, dst []byte) []byte {
+	dst = append(dst, "偩 =<'>?ABCD\t_Tab\n NewLine: \\backslash "...)
+	if v>>1&0x1 != 0 {
+		dst = append(dst, "Lo"...)
+	} else {
+		dst = append(dst, "lo"...)
+	}
+	dst = append(dst, "\nUp"...)
+	dst = append(dst, '0'+byte(v&0x1))
+	return dst
+}
+
+// SnapExtBlock appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'Type:'F 'EXT>[ Id:0xFHH 'ACK=]' RDY<[ not ready] D.10@
+func SnapExtBlock(v uint64, dst []byte) []byte {
+	dst = append(dst, "Type:"...)
+	dst = append(dst, '0'+byte(v>>24&0x7))
+	dst = append(dst, " "...)
+	if v>>23&0x1 != 0 {
+		dst = append(dst, "EXT"...)
+	}
+	if v>>23&0x1 != 0 {
+		dst = append(dst, " Id:0x"...)
+		dst = append(dst, '0'+byte(v>>20&0x7))
+		dst = append(dst, "0123456789ABCDEF"[v>>16&0xf])
+		dst = append(dst, "0123456789ABCDEF"[v>>12&0xf])
+		dst = append(dst, " "...)
+		if v>>11&0x1 != 0 {
+			dst = append(dst, "ACK"...)
+		} else {
+			dst = append(dst, "ack"...)
+		}
+	}
+	if v>>10&0x1 == 0 {
+		dst = append(dst, " RDY"...)
+	}
+	if v>>10&0x1 == 0 {
+		dst = append(dst, " not ready"...)
+	}
+	dst = append(dst, " "...)
+	{
+		var d [20]byte
+		i, x := len(d)-1, uint64(v&0x3ff)
+		for ; x > 9; i-- {
+			d[i] = byte('0' + x%10)
+			x /= 10
+		}
+		d[i] = byte('0' + x)
+		dst = append(dst, d[i:]...)
+	}
+	return dst
+}
+
+// SnapTaggedUnion appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'T:'F[ ping:HH| len:D.05@ ch:E!01@| 'ACK=.NAK=!06@| kind:E[ack|nak|rst]!06@| raw:HH]
+func SnapTaggedUnion(v uint64, dst []byte) []byte {
+	dst = append(dst, "T:"...)
+	dst = append(dst, '0'+byte(v>>8&0x7))
+	switch v >> 8 & 0x7 {
+	case 0:
+		dst = append(dst, " ping:"...)
+		dst = append(dst, "0123456789ABCDEF"[v>>4&0xf])
+		dst = append(dst, "0123456789ABCDEF"[v&0xf])
+	case 1:
+		dst = append(dst, " len:"...)
+		{
+			var d [20]byte
+			i, x := len(d)-1, uint64(v>>3&0x1f)
+			for ; x > 9; i-- {
+				d[i] = byte('0' + x%10)
+				x /= 10
+			}
+			d[i] = byte('0' + x)
+			dst = append(dst, d[i:]...)
+		}
+		dst = append(dst, " ch:"...)
+		dst = append(dst, '0'+byte(v>>1&0x3))
+	case 2:
+		dst = append(dst, " "...)
+		if v>>7&0x1 != 0 {
+			dst = append(dst, "ACK"...)
+		} else {
+			dst = append(dst, "ack"...)
+		}
+		if v>>6&0x1 != 0 {
+			dst = append(dst, ".NAK"...)
+		} else {
+			dst = append(dst, ".nak"...)
+		}
+	case 3:
+		dst = append(dst, " kind:"...)
+		dst = append(dst, '0'+byte(v>>6&0x3))
+		switch v >> 6 & 0x3 {
+		case 0:
+			dst = append(dst, "ack"...)
+		case 1:
+			dst = append(dst, "nak"...)
+		case 2:
+			dst = append(dst, "rst"...)
+		}
+	case 4:
+		dst = append(dst, " raw:"...)
+		dst = append(dst, "0123456789ABCDEF"[v>>4&0xf])
+		dst = append(dst, "0123456789ABCDEF"[v&0xf])
+	}
+	return dst
+}
+
+// SnapRepeats appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'bits:'B{16/4_} 'mac:'HH{6/1:}
+func SnapRepeats(v uint64, dst []byte) []byte {
+	dst = append(dst, "bits:"...)
+	dst = append(dst, '0'+byte(v>>63))
+	dst = append(dst, '0'+byte(v>>62&0x1))
+	dst = append(dst, '0'+byte(v>>61&0x1))
+	dst = append(dst, '0'+byte(v>>60&0x1))
+	dst = append(dst, "_"...)
+	dst = append(dst, '0'+byte(v>>59&0x1))
+	dst = append(dst, '0'+byte(v>>58&0x1))
+	dst = append(dst, '0'+byte(v>>57&0x1))
+	dst = append(dst, '0'+byte(v>>56&0x1))
+	dst = append(dst, "_"...)
+	dst = append(dst, '0'+byte(v>>55&0x1))
+	dst = append(dst, '0'+byte(v>>54&0x1))
+	dst = append(dst, '0'+byte(v>>53&0x1))
+	dst = append(dst, '0'+byte(v>>52&0x1))
+	dst = append(dst, "_"...)
+	dst = append(dst, '0'+byte(v>>51&0x1))
+	dst = append(dst, '0'+byte(v>>50&0x1))
+	dst = append(dst, '0'+byte(v>>49&0x1))
+	dst = append(dst, '0'+byte(v>>48&0x1))
+	dst = append(dst, " mac:"...)
+	dst = append(dst, "0123456789ABCDEF"[v>>44&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>40&0xf])
+	dst = append(dst, ":"...)
+	dst = append(dst, "0123456789ABCDEF"[v>>36&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>32&0xf])
+	dst = append(dst, ":"...)
+	dst = append(dst, "0123456789ABCDEF"[v>>28&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>24&0xf])
+	dst = append(dst, ":"...)
+	dst = append(dst, "0123456789ABCDEF"[v>>20&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>16&0xf])
+	dst = append(dst, ":"...)
+	dst = append(dst, "0123456789ABCDEF"[v>>12&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v>>8&0xf])
+	dst = append(dst, ":"...)
+	dst = append(dst, "0123456789ABCDEF"[v>>4&0xf])
+	dst = append(dst, "0123456789ABCDEF"[v&0xf])
+	return dst
+}
+
+// SnapBinary appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'reg:'B_20@ 'lo:'B.06@ 'x:'B:04@{3/1 }
+func SnapBinary(v uint64, dst []byte) []byte {
+	dst = append(dst, "reg:"...)
+	dst = append(dst, '0'+byte(v>>37&0x1))
+	dst = append(dst, '0'+byte(v>>36&0x1))
+	dst = append(dst, '0'+byte(v>>35&0x1))
+	dst = append(dst, '0'+byte(v>>34&0x1))
+	dst = append(dst, "_"...)
+	dst = append(dst, '0'+byte(v>>33&0x1))
+	dst = append(dst, '0'+byte(v>>32&0x1))
+	dst = append(dst, '0'+byte(v>>31&0x1))
+	dst = append(dst, '0'+byte(v>>30&0x1))
+	dst = append(dst, "_"...)
+	dst = append(dst, '0'+byte(v>>29&0x1))
+	dst = append(dst, '0'+byte(v>>28&0x1))
+	dst = append(dst, '0'+byte(v>>27&0x1))
+	dst = append(dst, '0'+byte(v>>26&0x1))
+	dst = append(dst, "_"...)
+	dst = append(dst, '0'+byte(v>>25&0x1))
+	dst = append(dst, '0'+byte(v>>24&0x1))
+	dst = append(dst, '0'+byte(v>>23&0x1))
+	dst = append(dst, '0'+byte(v>>22&0x1))
+	dst = append(dst, "_"...)
+	dst = append(dst, '0'+byte(v>>21&0x1))
+	dst = append(dst, '0'+byte(v>>20&0x1))
+	dst = append(dst, '0'+byte(v>>19&0x1))
+	dst = append(dst, '0'+byte(v>>18&0x1))
+	dst = append(dst, " lo:"...)
+	dst = append(dst, '0'+byte(v>>17&0x1))
+	dst = append(dst, '0'+byte(v>>16&0x1))
+	dst = append(dst, '0'+byte(v>>15&0x1))
+	dst = append(dst, '0'+byte(v>>14&0x1))
+	dst = append(dst, '0'+byte(v>>13&0x1))
+	dst = append(dst, '0'+byte(v>>12&0x1))
+	dst = append(dst, " x:"...)
+	dst = append(dst, '0'+byte(v>>11&0x1))
+	dst = append(dst, '0'+byte(v>>10&0x1))
+	dst = append(dst, '0'+byte(v>>9&0x1))
+	dst = append(dst, '0'+byte(v>>8&0x1))
+	dst = append(dst, " "...)
+	dst = append(dst, '0'+byte(v>>7&0x1))
+	dst = append(dst, '0'+byte(v>>6&0x1))
+	dst = append(dst, '0'+byte(v>>5&0x1))
+	dst = append(dst, '0'+byte(v>>4&0x1))
+	dst = append(dst, " "...)
+	dst = append(dst, '0'+byte(v>>3&0x1))
+	dst = append(dst, '0'+byte(v>>2&0x1))
+	dst = append(dst, '0'+byte(v>>1&0x1))
+	dst = append(dst, '0'+byte(v&0x1))
+	return dst
+}
+
+// SnapHexOct appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'id:0x'X.10@ 'lo:'x012@ 'oct:'O.09@ 'pad:'O010@ 'top:'x.20@
+func SnapHexOct(v uint64, dst []byte) []byte {
+	dst = append(dst, "id:0x"...)
+	{
+		var d [22]byte
+		i, x := len(d)-1, uint64(v>>51&0x3ff)
+		for ; x > 15; i-- {
+			d[i] = "0123456789ABCDEF"[x&15]
+			x >>= 4
+		}
+		d[i] = "0123456789ABCDEF"[x]
+		dst = append(dst, d[i:]...)
+	}
+	dst = append(dst, " lo:"...)
+	dst = append(dst, "0123456789abcdef"[v>>47&0xf])
+	dst = append(dst, "0123456789abcdef"[v>>43&0xf])
+	dst = append(dst, "0123456789abcdef"[v>>39&0xf])
+	dst = append(dst, " oct:"...)
+	{
+		var d [22]byte
+		i, x := len(d)-1, uint64(v>>30&0x1ff)
+		for ; x > 7; i-- {
+			d[i] = "01234567"[x&7]
+			x >>= 3
+		}
+		d[i] = "01234567"[x]
+		dst = append(dst, d[i:]...)
+	}
+	dst = append(dst, " pad:"...)
+	dst = append(dst, "01234567"[v>>29&0x1])
+	dst = append(dst, "01234567"[v>>26&0x7])
+	dst = append(dst, "01234567"[v>>23&0x7])
+	dst = append(dst, "01234567"[v>>20&0x7])
+	dst = append(dst, " top:"...)
+	{
+		var d [22]byte
+		i, x := len(d)-1, uint64(v&0xfffff)
+		for ; x > 15; i-- {
+			d[i] = "0123456789abcdef"[x&15]
+			x >>= 4
+		}
+		d[i] = "0123456789abcdef"[x]
+		dst = append(dst, d[i:]...)
+	}
+	return dst
+}
+
+// SnapFixed appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'T:'Q08.2.16@'C V:'U04.3.12@ 'avg:'Q32.9.36@
+func SnapFixed(v uint64, dst []byte) []byte {
+	dst = append(dst, "T:"...)
+	dst = bitpeek.AppendSnap(dst, "Q08.2.16@", v>>48)
+	dst = append(dst, "C V:"...)
+	dst = bitpeek.AppendSnap(dst, "U04.3.12@", v>>36&0xfff)
+	dst = append(dst, " avg:"...)
+	dst = bitpeek.AppendSnap(dst, "Q32.9.36@", v&0xfffffffff)
+	return dst
+}
+
+// SnapFloat appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'h:'Float16@' s:'Float32@
+func SnapFloat(v uint64, dst []byte) []byte {
+	dst = append(dst, "h:"...)
+	dst = bitpeek.AppendSnap(dst, "Float16@", v>>32&0xffff)
+	dst = append(dst, " s:"...)
+	dst = bitpeek.AppendSnap(dst, "Float32@", v&0xffffffff)
+	return dst
+}
+
+// SnapTime appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'at 'Tm0.42@' took 'Pm.22@
+func SnapTime(v uint64, dst []byte) []byte {
+	dst = append(dst, "at "...)
+	dst = bitpeek.AppendSnap(dst, "Tm0.42@", v>>22)
+	dst = append(dst, " took "...)
+	dst = bitpeek.AppendSnap(dst, "Pm.22@", v&0x3fffff)
+	return dst
+}
+
+// SnapIds appends what bitpeek.Snap would make of v with a picstring:
+//
+//	'id:'G2.40@ 'tok:'G4.24@
+func SnapIds(v uint64, dst []byte) []byte {
+	dst = append(dst, "id:"...)
+	dst = bitpeek.AppendSnap(dst, "G2.40@", v>>24)
+	dst = append(dst, " tok:"...)
+	dst = bitpeek.AppendSnap(dst, "G4.24@", v&0xffffff)
+	return dst
+}
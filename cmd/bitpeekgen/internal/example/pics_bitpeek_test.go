@@ -0,0 +1,52 @@
+// Code generated by bitpeekgen. DO NOT EDIT.
+
+//go:build !bitpeek_tiny
+
+package example
+
+import (
+	"testing"
+
+	"github.com/ohir/bitpeek"
+)
+
+func TestPicsBitpeek(t *testing.T) {
+	gen := []struct {
+		name string
+		pic  string
+		f    func(uint64, []byte) []byte
+	}{
+		{"SnapHeader", "'Type:'F 'EXT=.ACK= Id:0xFHH from IPv4.Address32@:D.16@", SnapHeader},
+		{"SnapShort", "'PT:'F 'EXT=.ACK= Id:0xFHH!48@", SnapShort},
+		{"SnapHex64", "HHHHHHHHHHHHHHHH", SnapHex64},
+		{"SnapIndicators", "'TX= RX> AK< ER? ", SnapIndicators},
+		{"SnapChars", "'Ascii:' A 'Char:' C 'C32s:' GG 'Octal:' 0EFF 'Bit:' B", SnapChars},
+		{"SnapBigDec", "D64................64@", SnapBigDec},
+		{"SnapEscapes", "偩 \\=\\<\\'\\>\\?\\A\\B\\C\\D\\t_Tab\\n NewLine: \\\\backslash 'Lo\\n=Up?", SnapEscapes},
+		{"SnapExtBlock", "'Type:'F 'EXT>[ Id:0xFHH 'ACK=]' RDY<[ not ready] D.10@", SnapExtBlock},
+		{"SnapTaggedUnion", "'T:'F[ ping:HH| len:D.05@ ch:E!01@| 'ACK=.NAK=!06@| kind:E[ack|nak|rst]!06@| raw:HH]", SnapTaggedUnion},
+		{"SnapRepeats", "'bits:'B{16/4_} 'mac:'HH{6/1:}", SnapRepeats},
+		{"SnapBinary", "'reg:'B_20@ 'lo:'B.06@ 'x:'B:04@{3/1 }", SnapBinary},
+		{"SnapHexOct", "'id:0x'X.10@ 'lo:'x012@ 'oct:'O.09@ 'pad:'O010@ 'top:'x.20@", SnapHexOct},
+		{"SnapFixed", "'T:'Q08.2.16@'C V:'U04.3.12@ 'avg:'Q32.9.36@", SnapFixed},
+		{"SnapFloat", "'h:'Float16@' s:'Float32@", SnapFloat},
+		{"SnapTime", "'at 'Tm0.42@' took 'Pm.22@", SnapTime},
+		{"SnapIds", "'id:'G2.40@ 'tok:'G4.24@", SnapIds},
+	}
+	vals := []uint64{0, 1 << 63, 0x5555555555555555, 0xaaaaaaaaaaaaaaaa,
+		0xafdfdeadbeef4d0e, 0x7841aabeeffdd37e, 0xffffffffffffffff}
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := 0; i < 256; i++ { // xorshift
+		x ^= x << 13
+		x ^= x >> 7
+		x ^= x << 17
+		vals = append(vals, x)
+	}
+	for _, g := range gen {
+		for _, v := range vals {
+			if o, e := string(g.f(v, nil)), string(bitpeek.Snap(g.pic, v)); o != e {
+				t.Errorf("%s(%#x): got >%s< want >%s<", g.name, v, o, e)
+			}
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package example
+
+import (
+	"testing"
+
+	"github.com/ohir/bitpeek"
+)
+
+var header uint64 = 0xafdfdeadbeef4d0e
+
+func BenchmarkSnapHeader(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = bitpeek.Snap(headerPic, header)
+	}
+}
+func BenchmarkGenHeader(b *testing.B) {
+	buf := make([]byte, 0, 64)
+	for i := 0; i < b.N; i++ {
+		_ = SnapHeader(header, buf[:0])
+	}
+}
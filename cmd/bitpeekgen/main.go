@@ -0,0 +1,246 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Command bitpeekgen compiles bitpeek picstrings into plain Go functions.
+//
+// Even the best interpreter pays for dispatch: every Snap call walks the
+// picstring again. Bitpeekgen reads picstrings annotated for the linter
+//
+//	//bitpeek:header
+//	const hdrPic = `'Type:'F 'EXT=.ACK= Id:0xFHH from IPv4.Address32@:D.16@`
+//
+// and emits a straight-line function for each of them:
+//
+//	func SnapHeader(v uint64, dst []byte) []byte
+//
+// that appends to dst exactly what bitpeek.Snap(hdrPic, v) would return.
+// Shifts and masks are unrolled and labels are constant-folded, only Float,
+// fixed point, time, duration and Alphabets fields, and unprintable A C
+// characters are left to bitpeek.AppendSnap. A test file that checks
+// generated functions against the bitpeek.Snap interpreter is written
+// alongside. It is not built with the bitpeek_tiny tag, that has no Snap.
+//
+// Usage:
+//
+//	//go:generate bitpeekgen [-m regexp] [-o out.go] file.go
+//
+// Function name is made of the tag: `//bitpeek:ext head` gives SnapExtHead.
+// Picstrings with an empty tag are not compiled, tags that are not matched
+// by -m regexp are ignored. Resulting names must be unique. By default
+// output goes to file_bitpeek.go and file_bitpeek_test.go.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tagged is a picstring found in the source with its linter tag.
+type tagged struct {
+	name string // generated function name
+	tag  string
+	pic  string
+	pos  token.Position
+}
+
+func main() {
+	match := flag.String("m", "", "compile only picstrings with tags matching `regexp`")
+	out := flag.String("o", "", "output `file`. Default is input_bitpeek.go")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: bitpeekgen [-m regexp] [-o out.go] file.go\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), *out, *match); err != nil {
+		fmt.Fprintf(os.Stderr, "bitpeekgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, match string) error {
+	var mre *regexp.Regexp
+	if match != "" {
+		var err error
+		if mre, err = regexp.Compile(match); err != nil {
+			return err
+		}
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, in, nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	pics, err := collect(fset, f, mre)
+	if err != nil {
+		return err
+	}
+	if len(pics) == 0 {
+		return fmt.Errorf("%s: no tagged picstrings found", in)
+	}
+	if out == "" {
+		out = strings.TrimSuffix(in, ".go") + "_bitpeek.go"
+	}
+	src, err := generate(f.Name.Name, pics)
+	if err != nil {
+		return err
+	}
+	tname := "Test" + camel(strings.TrimSuffix(filepath.Base(out), ".go"))
+	tsrc, err := generateTest(f.Name.Name, tname, pics)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(out, src, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(strings.TrimSuffix(out, ".go")+"_test.go", tsrc, 0644)
+}
+
+var annoRe = regexp.MustCompile(`^//bitpeek:([^:]*)(?::([0-7]))?\s*$`)
+
+// collect finds //bitpeek:tag:skip annotated string literals in f.
+func collect(fset *token.FileSet, f *ast.File, mre *regexp.Regexp) ([]tagged, error) {
+	var lits []*ast.BasicLit
+	ast.Inspect(f, func(n ast.Node) bool {
+		if bl, ok := n.(*ast.BasicLit); ok && bl.Kind == token.STRING {
+			lits = append(lits, bl)
+		}
+		return true
+	})
+	var pics []tagged
+	seen := make(map[string]token.Position)
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			m := annoRe.FindStringSubmatch(c.Text)
+			if m == nil {
+				continue
+			}
+			tag := strings.TrimSpace(m[1])
+			if tag == "" || mre != nil && !mre.MatchString(tag) {
+				continue
+			}
+			skip := 0
+			if m[2] != "" {
+				skip = int(m[2][0] - '0')
+			}
+			var lit *ast.BasicLit
+			for _, l := range lits {
+				if l.Pos() > c.End() {
+					if skip == 0 {
+						lit = l
+						break
+					}
+					skip--
+				}
+			}
+			pos := fset.Position(c.Pos())
+			if lit == nil {
+				return nil, fmt.Errorf("%s: no picstring for tag %q", pos, tag)
+			}
+			pic, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", pos, err)
+			}
+			name := "Snap" + camel(tag)
+			if p, dup := seen[name]; dup {
+				return nil, fmt.Errorf("%s: tag %q makes %s again (first at %s)", pos, tag, name, p)
+			}
+			seen[name] = pos
+			pics = append(pics, tagged{name: name, tag: tag, pic: pic, pos: fset.Position(lit.Pos())})
+		}
+	}
+	return pics, nil
+}
+
+// camel makes an exported Go identifier tail out of a tag: "ext head" gives
+// "ExtHead". Characters not allowed in identifiers separate words.
+func camel(s string) string {
+	var b []byte
+	up := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			if up {
+				c -= 0x20
+			}
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		default:
+			up = true
+			continue
+		}
+		up = false
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+func generate(pkg string, pics []tagged) ([]byte, error) {
+	var b, code strings.Builder
+	snap := false
+	for _, p := range pics {
+		ops, err := compile(p.pic)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", p.pos, err)
+		}
+		fmt.Fprintf(&code, "\n// %s appends what bitpeek.Snap would make of v with a picstring:\n", p.name)
+		for _, ln := range strings.Split(p.pic, "\n") {
+			fmt.Fprintf(&code, "//   %s\n", ln)
+		}
+		fmt.Fprintf(&code, "func %s(v uint64, dst []byte) []byte {\n", p.name)
+		emit(&code, ops)
+		code.WriteString("\treturn dst\n}\n")
+		snap = snap || uses(ops, "*AC")
+	}
+	fmt.Fprintf(&b, "// Code generated by bitpeekgen. DO NOT EDIT.\n\npackage %s\n", pkg)
+	if snap { // some fields are left to bitpeek.AppendSnap
+		b.WriteString("\nimport \"github.com/ohir/bitpeek\"\n")
+	}
+	b.WriteString(code.String())
+	return format.Source([]byte(b.String()))
+}
+
+func generateTest(pkg, tname string, pics []tagged) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by bitpeekgen. DO NOT EDIT.\n\n//go:build !bitpeek_tiny\n\npackage %s\n\n", pkg)
+	b.WriteString("import (\n\t\"testing\"\n\n\t\"github.com/ohir/bitpeek\"\n)\n\n")
+	fmt.Fprintf(&b, "func %s(t *testing.T) {\n", tname)
+	b.WriteString("\tgen := []struct {\n\t\tname string\n\t\tpic  string\n")
+	b.WriteString("\t\tf    func(uint64, []byte) []byte\n\t}{\n")
+	for _, p := range pics {
+		fmt.Fprintf(&b, "\t\t{%q, %s, %s},\n", p.name, strconv.Quote(p.pic), p.name)
+	}
+	b.WriteString("\t}\n")
+	b.WriteString(`	vals := []uint64{0, 1 << 63, 0x5555555555555555, 0xaaaaaaaaaaaaaaaa,
+		0xafdfdeadbeef4d0e, 0x7841aabeeffdd37e, 0xffffffffffffffff}
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := 0; i < 256; i++ { // xorshift
+		x ^= x << 13
+		x ^= x >> 7
+		x ^= x << 17
+		vals = append(vals, x)
+	}
+	for _, g := range gen {
+		for _, v := range vals {
+			if o, e := string(g.f(v, nil)), string(bitpeek.Snap(g.pic, v)); o != e {
+				t.Errorf("%s(%#x): got >%s< want >%s<", g.name, v, o, e)
+			}
+		}
+	}
+}
+`)
+	return format.Source([]byte(b.String()))
+}
@@ -0,0 +1,381 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+// Package dbc decodes CAN and CAN FD frames along a DBC file. Each signal
+// gets a bitpeek pic that shows its raw value: signed ones with the Q
+// command, ones scaled by a power of two as fixed point, value tables as
+// switch blocks. Signals with other scales or offsets are computed as float
+// and shown with as many decimals as their scale and offset have, so both
+// kinds keep their trailing zeros:
+//
+//	db, _ := dbc.Parse(f)
+//	fmt.Printf("%s\n", db.Decode(0x100, frame))
+//
+//	Output:
+//	EngineData 0x100: Speed:1200.5rpm Temp:-5degC Gear:D
+//
+// Frame ids are given as SocketCAN does: extended ones have bit 31 set,
+// same as they are written in DBC files. Only BO_, SG_ and VAL_ statements
+// are read, other ones are skipped. As SG_MUL_VAL_ is not read, extended
+// multiplexing works in part: a mNM signal shows as a mN one, and all
+// multiplexed signals follow the M multiplexor of their message.
+package dbc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ohir/bitpeek"
+)
+
+// Extended is the bit 31 flag of an extended (29 bit) frame id.
+const Extended = 1 << 31
+
+// DB is a parsed DBC file.
+type DB struct {
+	Messages []*Message
+	ids      map[uint32]*Message
+}
+
+// Message is a BO_ frame definition.
+type Message struct {
+	ID      uint32 // with the Extended flag
+	Name    string
+	Len     int // DLC, in bytes
+	Signals []*Signal
+}
+
+// Signal is a SG_ definition.
+type Signal struct {
+	Name      string
+	Start     int  // start bit as the DBC tells it
+	Len       int  // bits, up to 64
+	BigEndian bool // @0 Motorola, otherwise @1 Intel
+	Signed    bool
+	Scale     float64
+	Offset    float64
+	Min, Max  float64
+	Unit      string
+	Mux       int  // shown only if multiplexor is Mux. -1 for always.
+	IsMux     bool // signal is a multiplexor: M, or mNM
+	Values    map[int64]string
+
+	// Pic is what shows the raw value with bitpeek.Snap. It is empty for
+	// signals that Decode computes as float, and for value tables of
+	// signals wider than 8 bits.
+	Pic string
+
+	prec int // decimals of float value
+}
+
+// Func Parse reads a DBC file.
+func Parse(r io.Reader) (*DB, error) {
+	db := &DB{ids: make(map[uint32]*Message)}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	var m *Message
+	var stmt string // VAL_ can span lines
+	for ln := 1; sc.Scan(); ln++ {
+		line := strings.TrimSpace(sc.Text())
+		if stmt != "" {
+			stmt += " " + line
+		} else if strings.HasPrefix(line, "VAL_ ") {
+			stmt = line
+		}
+		var err error
+		switch {
+		case stmt != "":
+			if strings.HasSuffix(line, ";") {
+				err = db.values(stmt)
+				stmt = ""
+			}
+		case strings.HasPrefix(line, "BO_ "):
+			m, err = message(line)
+			if err == nil {
+				db.Messages = append(db.Messages, m)
+				db.ids[m.ID] = m
+			}
+		case strings.HasPrefix(line, "SG_ "):
+			if m == nil {
+				err = fmt.Errorf("signal out of message")
+				break
+			}
+			var s *Signal
+			if s, err = signal(line); err == nil {
+				m.Signals = append(m.Signals, s)
+			}
+		case line == "":
+			m = nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("dbc: line %d: %v", ln, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for _, m := range db.Messages {
+		for _, s := range m.Signals {
+			s.Pic = pic(s)
+			if s.prec = places(s.Scale); places(s.Offset) > s.prec {
+				s.prec = places(s.Offset)
+			}
+		}
+	}
+	return db, nil
+}
+
+// message parses BO_ 256 EngineData: 8 ECU
+func message(line string) (*Message, error) {
+	f := strings.Fields(line)
+	if len(f) < 4 || !strings.HasSuffix(f[2], ":") {
+		return nil, fmt.Errorf("bad BO_")
+	}
+	id, err := strconv.ParseUint(f[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("bad BO_ id %q", f[1])
+	}
+	n, err := strconv.Atoi(f[3])
+	if err != nil || n < 0 || n > 64 {
+		return nil, fmt.Errorf("bad BO_ length %q", f[3])
+	}
+	return &Message{ID: uint32(id), Name: strings.TrimSuffix(f[2], ":"), Len: n}, nil
+}
+
+// signal parses SG_ Speed m1 : 0|16@1+ (0.5,0) [0|8000] "rpm" ECU
+func signal(line string) (*Signal, error) {
+	c := strings.IndexByte(line, ':')
+	if c < 0 {
+		return nil, fmt.Errorf("bad SG_")
+	}
+	head := strings.Fields(line[:c])
+	s := &Signal{Mux: -1}
+	switch {
+	case len(head) == 2:
+	case len(head) == 3 && head[2] == "M":
+		s.IsMux = true
+	case len(head) == 3 && strings.HasPrefix(head[2], "m"): // mN or mNM
+		t := strings.TrimSuffix(head[2][1:], "M")
+		s.IsMux = len(t) < len(head[2])-1
+		n, err := strconv.Atoi(t)
+		if err != nil {
+			return nil, fmt.Errorf("bad SG_ multiplex %q", head[2])
+		}
+		s.Mux = n
+	default:
+		return nil, fmt.Errorf("bad SG_")
+	}
+	s.Name = head[1]
+	var order, sign byte
+	body := strings.TrimSpace(line[c+1:])
+	if _, err := fmt.Sscanf(body, "%d|%d@%c%c (%g,%g) [%g|%g] %q",
+		&s.Start, &s.Len, &order, &sign, &s.Scale, &s.Offset, &s.Min, &s.Max, &s.Unit); err != nil {
+		return nil, fmt.Errorf("bad SG_ %s: %v", s.Name, err)
+	}
+	if s.Len < 1 || s.Len > 64 || s.Start < 0 || s.Start > 511 ||
+		order != '0' && order != '1' || sign != '+' && sign != '-' {
+		return nil, fmt.Errorf("bad SG_ %s layout", s.Name)
+	}
+	s.BigEndian, s.Signed = order == '0', sign == '-'
+	return s, nil
+}
+
+// values parses VAL_ 256 Gear 0 "P" 1 "R" ;
+func (db *DB) values(stmt string) error {
+	t := tokens(strings.TrimSuffix(stmt, ";"))
+	if len(t) < 3 || len(t)%2 == 0 {
+		return fmt.Errorf("bad VAL_")
+	}
+	id, err := strconv.ParseUint(t[1], 10, 32)
+	if err != nil {
+		return fmt.Errorf("bad VAL_ id %q", t[1])
+	}
+	m := db.ids[uint32(id)]
+	if m == nil {
+		return nil // VAL_ of an environment variable or of no message
+	}
+	var s *Signal
+	for _, x := range m.Signals {
+		if x.Name == t[2] {
+			s = x
+		}
+	}
+	if s == nil {
+		return fmt.Errorf("VAL_ of no signal %s", t[2])
+	}
+	s.Values = make(map[int64]string)
+	for i := 3; i < len(t); i += 2 {
+		v, err := strconv.ParseInt(t[i], 10, 64)
+		if err != nil || !strings.HasPrefix(t[i+1], `"`) {
+			return fmt.Errorf("bad VAL_ of %s", s.Name)
+		}
+		s.Values[v] = strings.Trim(t[i+1], `"`)
+	}
+	return nil
+}
+
+// tokens splits s at spaces, keeping "quoted text" whole.
+func tokens(s string) []string {
+	var t []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		e := strings.IndexAny(s, " \t")
+		if s[0] == '"' {
+			e = strings.IndexByte(s[1:], '"') + 2
+		}
+		if e <= 0 || e > len(s) {
+			e = len(s)
+		}
+		t = append(t, s[:e])
+		s = s[e:]
+	}
+	return t
+}
+
+// Message returns the message of a frame id, nil if there is none.
+func (db *DB) Message(id uint32) *Message {
+	return db.ids[id]
+}
+
+// Decode shows a frame: message name, id and its signals. Multiplexed
+// signals show only if the multiplexor value is theirs. Frames of unknown
+// ids show their id and data bytes in hex.
+func (db *DB) Decode(id uint32, data []byte) []byte {
+	idp := `'0x'X011@`
+	if id&Extended != 0 {
+		idp = `'0x'X029@`
+	}
+	m := db.ids[id]
+	if m == nil {
+		b := append(bitpeek.Snap(idp, uint64(id)), ':')
+		for _, c := range data {
+			b = append(b, ' ')
+			b = append(b, bitpeek.Snap(`x008@`, uint64(c))...)
+		}
+		return b
+	}
+	b := append([]byte(m.Name+" "), bitpeek.Snap(idp, uint64(id))...)
+	b = append(b, ':')
+	mux := int64(-1)
+	for _, s := range m.Signals {
+		if s.IsMux && s.Mux < 0 {
+			mux = int64(s.Raw(data))
+		}
+	}
+	for _, s := range m.Signals {
+		if s.Mux >= 0 && int64(s.Mux) != mux {
+			continue
+		}
+		b = append(b, ' ')
+		b = s.Append(b, data)
+	}
+	return b
+}
+
+// Append appends the signal name and value, as read from data, to b.
+func (s *Signal) Append(b, data []byte) []byte {
+	raw := s.Raw(data)
+	if s.Pic != "" {
+		return append(b, bitpeek.Snap(s.Pic, raw)...)
+	}
+	v := int64(raw)
+	if s.Signed && s.Len < 64 {
+		v = v << (64 - s.Len) >> (64 - s.Len)
+	}
+	b = append(b, s.Name+":"...)
+	if t, ok := s.Values[v]; ok {
+		return append(b, t...)
+	}
+	f := float64(raw)
+	if s.Signed {
+		f = float64(v)
+	}
+	b = strconv.AppendFloat(b, f*s.Scale+s.Offset, 'f', s.prec, 64)
+	return append(b, s.Unit...)
+}
+
+// Raw returns the raw bits of s in data, not sign extended. Bits past
+// the data end read as zeros.
+func (s *Signal) Raw(data []byte) uint64 {
+	var v uint64
+	bit := func(i int) uint64 {
+		if i>>3 >= len(data) {
+			return 0
+		}
+		return uint64(data[i>>3]>>(i&7)) & 1
+	}
+	if s.BigEndian { // Start is the msb, bits run down and to the next byte
+		for i, n := s.Start, 0; n < s.Len; n++ {
+			v = v<<1 | bit(i)
+			if i&7 == 0 {
+				i += 15
+			} else {
+				i--
+			}
+		}
+		return v
+	}
+	for n := s.Len - 1; n >= 0; n-- {
+		v = v<<1 | bit(s.Start+n)
+	}
+	return v
+}
+
+// pic makes the bitpeek pic for s, or returns "" if s can not have one.
+func pic(s *Signal) string {
+	name := "'" + quote(s.Name) + ":'"
+	dd := fmt.Sprintf("%02d@", s.Len)
+	unit := ""
+	if s.Unit != "" {
+		unit = "'" + quote(s.Unit) + "'"
+	}
+	if s.Values != nil { // a variant for each raw value, decimal if not in table
+		if s.Len > 8 || s.Signed || s.Scale != 1 || s.Offset != 0 {
+			return ""
+		}
+		var vs []string
+		for k := int64(0); k < 1<<s.Len; k++ {
+			if t, ok := s.Values[k]; ok {
+				vs = append(vs, "'"+quote(t)+"'")
+			} else {
+				vs = append(vs, strconv.FormatInt(k, 10))
+			}
+		}
+		return name + "!" + dd + "[" + strings.Join(vs, "|") + "]"
+	}
+	f := -math.Log2(s.Scale)
+	switch {
+	case s.Offset != 0 || f != math.Trunc(f) || f < 0 || f > float64(s.Len) || f > 9:
+		return ""
+	case f > 0 && s.Signed:
+		return name + fmt.Sprintf("Q%02d.%d.", int(f), int(f)) + dd + unit
+	case f > 0:
+		return name + fmt.Sprintf("U%02d.%d.", int(f), int(f)) + dd + unit
+	case s.Signed:
+		return name + "Q00.0." + dd + unit
+	case s.Len > 16:
+		return name + "D" + strings.Repeat(".", s.Len/3-3) + dd + unit
+	}
+	return name + "D." + dd + unit
+}
+
+// places returns how many decimals x has, 0 for a whole number.
+func places(x float64) int {
+	t := strconv.FormatFloat(x, 'f', -1, 64)
+	if i := strings.IndexByte(t, '.'); i >= 0 {
+		return len(t) - i - 1
+	}
+	return 0
+}
+
+// quote escapes characters that pic would interpret in a quoted text.
+// Backslash, that can not be escaped safely, is replaced by /.
+func quote(s string) string {
+	return strings.NewReplacer(`\`, `/`, `'`, `\'`, `|`, `\|`, `[`, `\[`, `]`, `\]`).Replace(s)
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package dbc
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohir/bitpeek"
+)
+
+const vehicle = `VERSION ""
+
+NS_ :
+	CM_
+	VAL_
+
+BS_:
+
+BU_: ECU Dash
+
+BO_ 256 EngineData: 8 ECU
+ SG_ Speed : 0|16@1+ (0.5,0) [0|8000] "rpm" Dash
+ SG_ Temp : 16|8@1- (1,0) [-40|215] "degC" Dash
+ SG_ Gear : 24|3@1+ (1,0) [0|7] "" Dash
+ SG_ Volts : 39|12@0+ (0.01,0) [0|40.95] "V" Dash
+
+BO_ 2566844693 Battery: 8 ECU
+ SG_ Page M : 0|8@1+ (1,0) [0|255] "" Dash
+ SG_ Cell1 m0 : 15|16@0- (0.125,0) [-4096|4096] "A" Dash
+ SG_ Cell2 m0 : 31|16@0+ (1,-40) [-40|65495] "degC" Dash
+ SG_ Serial m1 : 8|40@1+ (1,0) [0|0] "" Dash
+ SG_ State m1 : 48|2@1+ (1,0) [0|3] "" Dash
+
+BO_ 512 Diag: 8 ECU
+ SG_ Mode M : 0|8@1+ (1,0) [0|255] "" Dash
+ SG_ Sub m1M : 8|8@1+ (1,0) [0|255] "" Dash
+ SG_ Code m1 : 16|8@1+ (1,0) [0|255] "" Dash
+
+CM_ SG_ 256 Speed "Engine speed";
+VAL_ 256 Gear 0 "P" 1 "R" 2 "N" 3 "D" 5 "Sport|Eco" ;
+VAL_ 2566844693 State 0 "off"
+  3 "fault" ;
+`
+
+func ExampleDB_Decode() {
+	db, err := Parse(strings.NewReader(vehicle))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s\n", db.Decode(0x100, []byte{0x61, 0x09, 0xfb, 0x03, 0x4d, 0x20}))
+	fmt.Printf("%s\n", db.Decode(0x80000000|0x18fef115, []byte{0x00, 0xff, 0xf0, 0x00, 0x64}))
+	fmt.Printf("%s\n", db.Decode(0x7ff, []byte{0xca, 0xfe}))
+
+	// Output:
+	// EngineData 0x100: Speed:1200.5rpm Temp:-5degC Gear:D Volts:12.34V
+	// Battery 0x18FEF115: Page:0 Cell1:-2.000A Cell2:60degC
+	// 0x7FF: ca fe
+}
+
+var decodeTests = []struct {
+	name string
+	id   uint32
+	data []byte
+	out  string
+}{
+	{`gap in table`, 0x100, []byte{0, 0, 0, 4}, `EngineData 0x100: Speed:0.0rpm Temp:0degC Gear:4 Volts:0.00V`},
+	{`past table`, 0x100, []byte{0, 0, 0, 6}, `EngineData 0x100: Speed:0.0rpm Temp:0degC Gear:6 Volts:0.00V`},
+	{`top past table`, 0x100, []byte{0, 0, 0, 7}, `EngineData 0x100: Speed:0.0rpm Temp:0degC Gear:7 Volts:0.00V`},
+	{`escaped`, 0x100, []byte{0, 0, 0, 5}, `EngineData 0x100: Speed:0.0rpm Temp:0degC Gear:Sport|Eco Volts:0.00V`},
+	{`float zeros`, 0x100, []byte{0, 0, 0, 0, 0x4b, 0x00}, `EngineData 0x100: Speed:0.0rpm Temp:0degC Gear:P Volts:12.00V`},
+	{`short frame`, 0x100, []byte{0xff}, `EngineData 0x100: Speed:127.5rpm Temp:0degC Gear:P Volts:0.00V`},
+	{`mux 1`, 0x80000000 | 0x18fef115,
+		[]byte{0x01, 0x01, 0x02, 0x03, 0x04, 0x05, 0x03},
+		`Battery 0x18FEF115: Page:1 Serial:21542142465 State:fault`},
+	{`mux none`, 0x80000000 | 0x18fef115, []byte{0x07}, `Battery 0x18FEF115: Page:7`},
+	{`extended mux`, 0x200, []byte{1, 7, 9}, `Diag 0x200: Mode:1 Sub:7 Code:9`},
+	{`extended mux off`, 0x200, []byte{2, 7, 9}, `Diag 0x200: Mode:2`},
+	{`standard id`, 0x18fef115, nil, `0x115:`},
+}
+
+func TestDecode(t *testing.T) {
+	db, err := Parse(strings.NewReader(vehicle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range decodeTests {
+		if o := string(db.Decode(v.id, v.data)); o != v.out {
+			t.Errorf("%s is broken! o≢e >%s< ≢ >%s<", v.name, o, v.out)
+		}
+	}
+	for _, m := range db.Messages {
+		for _, s := range m.Signals {
+			if s.Pic == "" {
+				continue
+			}
+			if err := bitpeek.Check(s.Pic); err != nil {
+				t.Errorf("%s: %s %v", s.Name, s.Pic, err)
+			}
+		}
+	}
+	if m := db.Message(0x100); m == nil || len(m.Signals) != 4 || m.Len != 8 {
+		t.Errorf("EngineData is %+v", m)
+	}
+}
+
+func TestRaw(t *testing.T) {
+	data := []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}
+	for _, v := range []struct {
+		s   Signal
+		raw uint64
+	}{
+		{Signal{Start: 0, Len: 8}, 0x12},
+		{Signal{Start: 4, Len: 8}, 0x41},
+		{Signal{Start: 0, Len: 64}, 0xf0debc9a78563412},
+		{Signal{Start: 7, Len: 8, BigEndian: true}, 0x12},
+		{Signal{Start: 3, Len: 8, BigEndian: true}, 0x23},
+		{Signal{Start: 7, Len: 64, BigEndian: true}, 0x123456789abcdef0},
+		{Signal{Start: 63, Len: 4, BigEndian: true}, 0xf},
+		{Signal{Start: 60, Len: 8}, 0xf},
+	} {
+		if o := v.s.Raw(data); o != v.raw {
+			t.Errorf("%+v: got %#x want %#x", v.s, o, v.raw)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, v := range []struct {
+		dbc string
+		err string
+	}{
+		{"BO_ x Msg: 8 ECU", `line 1: bad BO_ id`},
+		{"BO_ 1 Msg 8 ECU", `line 1: bad BO_`},
+		{"BO_ 1 Msg: 99 ECU", `line 1: bad BO_ length`},
+		{" SG_ A : 0|8@1+ (1,0) [0|1] \"\" X", `line 1: signal out of message`},
+		{"BO_ 1 Msg: 8 ECU\n SG_ A : 0|80@1+ (1,0) [0|1] \"\" X", `line 2: bad SG_ A layout`},
+		{"BO_ 1 Msg: 8 ECU\n SG_ A : 0|8@2+ (1,0) [0|1] \"\" X", `line 2: bad SG_ A layout`},
+		{"BO_ 1 Msg: 8 ECU\n SG_ A : 0|8@1+ 1,0 [0|1] \"\" X", `line 2: bad SG_ A:`},
+		{"BO_ 1 Msg: 8 ECU\n SG_ A mx : 0|8@1+ (1,0) [0|1] \"\" X", `line 2: bad SG_ multiplex`},
+		{"BO_ 1 Msg: 8 ECU\n SG_ A mM : 0|8@1+ (1,0) [0|1] \"\" X", `line 2: bad SG_ multiplex`},
+		{"BO_ 1 Msg: 8 ECU\n SG_ A : 0|8@1+ (1,0) [0|1] \"\" X\nVAL_ 1 B 0 \"x\" ;", `line 3: VAL_ of no signal B`},
+		{"BO_ 1 Msg: 8 ECU\n SG_ A : 0|8@1+ (1,0) [0|1] \"\" X\nVAL_ 1 A 0 ;", `line 3: bad VAL_`},
+		{"BO_ 1 Msg: 8 ECU\n SG_ A : 0|8@1+ (1,0) [0|1] \"\" X\nVAL_ 1 A x \"y\" ;", `line 3: bad VAL_ of A`},
+	} {
+		_, err := Parse(strings.NewReader(v.dbc))
+		if err == nil || !strings.Contains(err.Error(), v.err) {
+			t.Errorf("%q: got %v, want %s", v.dbc, err, v.err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+// DiffMark is put around fields that SnapDiff finds changed. Set it once,
+// before use. Eg. {"\x1b[7m", "\x1b[0m"} shows changes in inverse video on
+// ANSI terminals.
+var DiffMark = [2]string{"[", "]"}
+
+// Func SnapDiff renders pic for the now value, same as Snap does, then puts
+// DiffMark around every field that has any of its bits changed since was.
+// For SnapDiff a field is made by a command (or a label) and the text on its
+// left up to the previous command. Eg. `'Type:'F 'EXT=.ACK= Id:0xFHH` has
+// five fields: `Type:F`, ` EXT=`, `.ACK=`, ` Id:0xF` and `HH`. Text on the
+// right of the last command belongs to no field. Label that disappeared
+// (> or < command) shows as empty marks.
+//
+//    bitpeek.SnapDiff(`'Type:'F 'EXT=.ACK= Id:0xFHH`, 0xafdf, 0xa7df)
+//
+//    Output:
+//    Type:5 ext[.ack] Id:0x7DF
+//
+func SnapDiff(pic string, was, now uint64) []byte {
+	p := peek{dif: was ^ now, mark: true}
+	ot, oi := snap(pic, now, make([]byte, len(pic)), len(pic), &p)
+	return ot[oi:]
+}
+
+// Func SnapChanged renders only fields that SnapDiff would mark. State
+// transition logs need not to repeat what is known already:
+//
+//    bitpeek.SnapChanged(`'Type:'F 'EXT=.ACK= Id:0xFHH`, 0xafdf, 0xa7df)
+//
+//    Output:
+//    .ack
+//
+func SnapChanged(pic string, was, now uint64) []byte {
+	p := peek{dif: was ^ now, only: true}
+	ot, oi := snap(pic, now, make([]byte, len(pic)), len(pic), &p)
+	return ot[oi:]
+}
+
+// cut closes the field made by the previous command and the text on its
+// left, as w command opens the next one. At pic end w is 0.
+func (p *peek) cut(ot []byte, oi, pi int, nb uint, w byte) ([]byte, int) {
+	switch w {
+	case '?', '>', '<', '=', 'B', 'E', 'F', 'H', 'G', 'A', 'C', '@', 0:
+	default:
+		return ot, oi
+	}
+	if p.pack {
+		p.field(pi, nb, w)
+	}
+	if !p.mark && !p.only {
+		return ot, oi
+	}
+	if n := nb - p.fb; n != 0 {
+		var x uint64 // changed bits of the field
+		if p.fb < 64 {
+			x = p.dif >> p.fb
+			if n < 64 {
+				x &= 1<<n - 1
+			}
+		}
+		if (x != 0) == p.mark { // to mark, or to drop
+			p.fs = append(p.fs, dfield{p.fe, len(ot) - oi, p.fb})
+		}
+	}
+	p.fb = nb
+	p.fe = len(ot) - oi
+	if w == 0 {
+		return p.marks(ot[oi:]), 0
+	}
+	return ot, oi
+}
+
+// marks returns out with DiffMark put around fields of p.fs, or with these
+// fields dropped for SnapChanged. Fields are marked once the pic is done,
+// as blocks may still hide or redo their output.
+func (p *peek) marks(out []byte) []byte {
+	if len(p.fs) == 0 {
+		return out
+	}
+	nt := make([]byte, 0, len(out)+len(p.fs)*(len(DiffMark[0])+len(DiffMark[1])))
+	x := 0 // out is copied up to x
+	for i := len(p.fs) - 1; i >= 0; i-- { // left to right
+		f := p.fs[i]
+		s, e := len(out)-f.s, len(out)-f.e
+		nt = append(nt, out[x:s]...)
+		if p.mark {
+			nt = append(nt, DiffMark[0]...)
+			nt = append(nt, out[s:e]...)
+			nt = append(nt, DiffMark[1]...)
+		}
+		x = e
+	}
+	return append(nt, out[x:]...)
+}
+
+// open notes the state of fields at the start of the block b.
+func (p *peek) open(b *blk) {
+	b.pn, b.pb, b.pe = len(p.fs), p.fb, p.fe
+}
+
+// redo brings fields back to the start of the switch block b, as it parses
+// another variant.
+func (p *peek) redo(b *blk) {
+	p.fs = p.fs[:b.pn]
+	p.fb, p.fe = b.pb, b.pe
+}
+
+// hide drops fields of the block b that hid its output. A field open at the
+// block start keeps its part on the right of the block. Bits of the block
+// belong to no field, so a hidden change is not marked. Bits up to nb are
+// taken.
+func (p *peek) hide(b *blk, nb uint) {
+	if !p.mark && !p.only {
+		return
+	}
+	fs := p.fs[:b.pn]
+	for _, f := range p.fs[b.pn:] {
+		if f.fb < b.nb {
+			if f.s > b.e {
+				f.s = b.e
+			}
+			fs = append(fs, f)
+		}
+	}
+	p.fs = fs
+	if p.fb >= b.nb {
+		p.fb = nb
+	}
+	if p.fe > b.e {
+		p.fe = b.e
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func ExampleSnapDiff() {
+	pic := `'Type:'F' EXT=' ACK=' Id:'D.11@`
+	was := uint64(0xafdf)
+	for _, now := range []uint64{0xafdf, 0xa7df, 0xdfd1, 0x8fd0} {
+		fmt.Printf("%-34s|%s\n", SnapDiff(pic, was, now), SnapChanged(pic, was, now))
+		was = now
+	}
+	// Output:
+	// Type:5 ext ACK Id:2015            |
+	// Type:5 ext[ ack] Id:2015          | ack
+	// [Type:6][ EXT][ ACK][ Id:2001]    |Type:6 EXT ACK Id:2001
+	// [Type:4][ ext] ACK[ Id:2000]      |Type:4 ext Id:2000
+}
+
+var diffTests = []struct {
+	name     string
+	pic      string
+	was, now uint64
+	diff     string
+	changed  string
+}{
+	//bitpeek:diff:2
+	{`no change`, `'Type:'F 'EXT=.ACK= Id:0xFHH`, 0xafdf, 0xafdf,
+		`Type:5 ext.ACK Id:0x7DF`, ``},
+	//bitpeek:diff:2
+	{`repeat`, `B{8/4}`, 0xa5, 0xb5,
+		`101[1] 0101`, `1`},
+	//bitpeek:diff:2
+	{`hex flock`, `'Type:'F 'EXT=.ACK= Id:0xFHH`, 0xafdf, 0xafd0,
+		`Type:5 ext.ACK Id:0x7[D0]`, `D0`},
+	//bitpeek:diff:2
+	{`label gone`, `'TX> RX> AK> ER>\n`, 0xf, 0xb,
+		"TX[] AK ER\n", "\n"},
+	//bitpeek:diff:2
+	{`label back`, `'TX> RX> AK> ER>\n`, 0xb, 0xf,
+		"TX[ RX] AK ER\n", " RX\n"},
+	//bitpeek:diff:2
+	{`decimals`, `'Port:'D.16@ from IPv4.Address32@`, 0x1000a0000051, 0x1000a0010051,
+		`Port:4096[ from 160.1.0.81]`, ` from 160.1.0.81`},
+	//bitpeek:diff:2
+	{`skipped`, `'Type:'F 'EXT=.ACK= Id:0xFHH!48@`, 0, 0xffffffffffff,
+		`Type:0 ext.ack Id:0x000`, ``},
+	//bitpeek:diff:2
+	{`block gone`, `'EXT>[ Id:HH]!`, 0x1ab, 0x0ab, `[]!`, `!`},
+	//bitpeek:diff:2
+	{`block back`, `'EXT>[ Id:HH]!`, 0x0ab, 0x1ac, `[EXT][ Id:AC]!`, `EXT Id:AC!`},
+	//bitpeek:diff:2
+	{`wide marks`, `' b0:?`, 0, 1, `[ b0:1]`, ` b0:1`},
+	//bitpeek:diff:2
+	{`no room`, `?>`, 0, 3, `[1][]`, `1`},
+	//bitpeek:diff:2
+	{`no room label`, `B'ON>`, 0, 3, `[1][ON]`, `1ON`},
+	//bitpeek:diff:2
+	{`next blocks`, `'A>[HH]'B>[HH]`, 0x0ff, 0x3ff, `[B]FF`, `B`},
+	//bitpeek:diff:2
+	{`next blocks back`, `'A>[HH]'B>[HH]`, 0x000, 0x3ff, `[B][FF]`, `BFF`},
+	//bitpeek:diff:2
+	{`both blocks`, `'A>[HH]'B>[HH]`, 0x0ff, 0x20301, `[A][01][B][01]`, `A01B01`},
+	//bitpeek:diff:2
+	{`label before block`, `?EXT>[ Id:HH]`, 0x2cf, 0x0a6, `[0]`, `0`},
+	//bitpeek:diff:2
+	{`hidden change`, `'EXT>[ Id:HH]!`, 0x0ab, 0x0ac, `!`, `!`},
+	//bitpeek:diff:2
+	{`text in block`, `'X>[ xx]B`, 0x3, 0x2, `X[ xx0]`, ` xx0`},
+	//bitpeek:diff:2
+	{`text in hidden block`, `'X>[ xx]B`, 0x3, 0x0, `[][0]`, `0`},
+	//bitpeek:diff:2
+	{`nul`, "'x'\x00B", 0, 1, `[x1]`, `x1`},
+	//bitpeek:diff:2
+	{`switch`, `'T:'E[ a:B| b:HH| c:F!05@| d:H!04@]`, 0x100, 0x2a5, `[T:2][ c:5]`, `T:2 c:5`},
+	//bitpeek:diff:2
+	{`switch same`, `'T:'E[ a:B| b:HH| c:F!05@| d:H!04@]`, 0x2a0, 0x2e5, `T:2[ c:7]`, ` c:7`},
+}
+
+func TestSnapDiff(t *testing.T) {
+	for _, v := range diffTests {
+		if o := string(SnapDiff(v.pic, v.was, v.now)); o != v.diff {
+			t.Errorf("%s: SnapDiff o≢e >%s< ≢ >%s<", v.name, o, v.diff)
+		}
+		if o := string(SnapChanged(v.pic, v.was, v.now)); o != v.changed {
+			t.Errorf("%s: SnapChanged o≢e >%s< ≢ >%s<", v.name, o, v.changed)
+		}
+	}
+	defer func(m [2]string) { DiffMark = m }(DiffMark)
+	DiffMark = [2]string{"\x1b[7m", "\x1b[0m"}
+	if o, e := string(SnapDiff(`'B1= B0=`, 1, 2)), "\x1b[7mB1\x1b[0m\x1b[7m b0\x1b[0m"; o != e {
+		t.Errorf("ANSI marks: o≢e >%q< ≢ >%q<", o, e)
+	}
+}
+
+// TestSnapDiffRandom checks that SnapDiff of random pics is Snap with some
+// fields marked, and that SnapChanged keeps all of them.
+func TestSnapDiffRandom(t *testing.T) {
+	defer func(m [2]string) { DiffMark = m }(DiffMark)
+	DiffMark = [2]string{"\x01", "\x02"}
+	toks := []string{`B`, `E`, `F`, `HH`, `?`, `ON>`, `off<`, `Up=`, ` `, `'q:'`,
+		`D.04@`, `!02@`, `>[ x:HH]`, `<[ yy]`, `'L>[B]`, `>[ z]`, `'M<[ n:F'O>[E]]`,
+		`E[ a| bb:D.02@| ccc!02@]`, `B[|D.03@!01@]`, `F{2}`, `B{4/2}`}
+	r := rand.New(rand.NewSource(27))
+	for i := 0; i < 3000; i++ {
+		pic := ""
+		for j := 1 + r.Intn(6); j > 0; j-- {
+			pic += toks[r.Intn(len(toks))]
+		}
+		was, now := r.Uint64(), r.Uint64()
+		if r.Intn(2) == 0 {
+			now = was ^ 1<<r.Intn(24)
+		}
+		d := string(SnapDiff(pic, was, now))
+		if o, e := strings.NewReplacer("\x01", "", "\x02", "").Replace(d), string(Snap(pic, now)); o != e {
+			t.Fatalf("SnapDiff(%q, %#x, %#x) is not Snap! o≢e >%q< ≢ >%q<", pic, was, now, o, e)
+		}
+		c := string(SnapChanged(pic, was, now))
+		for _, f := range strings.Split(d, "\x01")[1:] {
+			x := strings.Split(f, "\x02")
+			if len(x) != 2 || !strings.Contains(c, x[0]) {
+				t.Fatalf("SnapDiff(%q, %#x, %#x) >%q< does not fit SnapChanged >%q<", pic, was, now, d, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+// Package dissect decodes stacked protocol headers. A Layer has a header
+// pic, a length expression and a field whose value picks the next Layer:
+//
+//	ipv4 := &dissect.Layer{Header: presets.IPv4, Length: "ihl*4", Next: "proto",
+//		Over: map[uint64]*dissect.Layer{6: tcp, 17: udp}}
+//
+//	parts, _ := dissect.Dissect(ipv4, pkt)
+//	for _, p := range parts {
+//		fmt.Printf("%s\n", p.Line)
+//	}
+//
+// Ethernet and IPv4 are ready made layers over presets headers.
+package dissect
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/ohir/bitpeek/presets"
+)
+
+// Layer is a protocol header in a stack of them.
+type Layer struct {
+	Header *presets.Header
+
+	// Length of the header in bytes. It is a sum of terms: numbers, fields
+	// and fields times a number, eg. "ihl*4" or "len+8". Empty Length is
+	// Header.Len.
+	Length string
+
+	// Next names the field whose value picks the next layer from Over.
+	// Empty Next ends the stack.
+	Next string
+	Over map[uint64]*Layer
+
+	// Stop names a field that ends the stack when it is not zero, eg. frag
+	// of IPv4, as fragments past the first do not start with a next header.
+	Stop string
+}
+
+// Part is a layer found in data.
+type Part struct {
+	Layer *Layer
+	Off   int    // where header starts in data
+	Len   int    // header length, may reach past data end
+	Line  []byte // Header.Snap of the header
+}
+
+// Func Dissect decodes data from the root layer on. It stops at a layer that
+// has no next one or has its Stop field set, when data ends, or when a
+// header says it is shorter than its fixed part. Bytes after the last part
+// are its payload. Error is returned only for a bad Length, Next or Stop of
+// a layer.
+func Dissect(root *Layer, data []byte) ([]Part, error) {
+	var parts []Part
+	off := 0
+	for l := root; l != nil && off < len(data); {
+		h := data[off:]
+		n, err := l.length(h)
+		if err != nil {
+			return parts, err
+		}
+		parts = append(parts, Part{l, off, n, l.Header.Snap(h)})
+		if l.Next == "" || n < l.Header.Len {
+			break
+		}
+		if l.Stop != "" {
+			v, ok := l.Header.Get(h, l.Stop)
+			if !ok {
+				return parts, errors.New("dissect: " + l.Header.Name + " has no " + l.Stop + " field")
+			}
+			if v != 0 {
+				break
+			}
+		}
+		v, ok := l.Header.Get(h, l.Next)
+		if !ok {
+			return parts, errors.New("dissect: " + l.Header.Name + " has no " + l.Next + " field")
+		}
+		l, off = l.Over[v], off+n
+	}
+	return parts, nil
+}
+
+// length evaluates l.Length over header h.
+func (l *Layer) length(h []byte) (int, error) {
+	if l.Length == "" {
+		return l.Header.Len, nil
+	}
+	n := 0
+	for _, t := range strings.Split(l.Length, "+") {
+		f, m := strings.TrimSpace(t), "1"
+		if i := strings.IndexByte(f, '*'); i >= 0 {
+			f, m = strings.TrimSpace(f[:i]), strings.TrimSpace(f[i+1:])
+		}
+		x, err := strconv.Atoi(m)
+		if err != nil {
+			return 0, errors.New("dissect: " + l.Header.Name + ": bad Length " + l.Length)
+		}
+		if v, err := strconv.Atoi(f); err == nil {
+			n += v * x
+			continue
+		}
+		v, ok := l.Header.Get(h, f)
+		if !ok {
+			return 0, errors.New("dissect: " + l.Header.Name + ": bad Length " + l.Length)
+		}
+		n += int(v) * x
+	}
+	return n, nil
+}
+
+// IPv4 is an IPv4 layer followed by ICMP, TCP or UDP. Fragments past the
+// first end the stack, the first one still starts with the next header.
+var IPv4 = &Layer{
+	Header: presets.IPv4,
+	Length: "ihl*4",
+	Next:   "proto",
+	Stop:   "frag",
+	Over: map[uint64]*Layer{
+		1:  {Header: presets.ICMP},
+		6:  {Header: presets.TCP, Length: "off*4"},
+		17: {Header: presets.UDP},
+	},
+}
+
+// Ethernet is an Ethernet II layer followed by IPv4.
+var Ethernet = &Layer{
+	Header: presets.Ethernet,
+	Next:   "type",
+	Over:   map[uint64]*Layer{0x0800: IPv4},
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package dissect
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohir/bitpeek/presets"
+)
+
+// TCP SYN with 4 bytes of options, in IPv4 with 4 bytes of options.
+var syn = []byte{
+	0x00, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e, 0x52, 0x54, 0x00, 0x12, 0x34, 0x56, 0x08, 0x00,
+	0x46, 0x00, 0x00, 0x30, 0x20, 0x00, 0x40, 0x00, 0x40, 0x06, 0x00, 0x00,
+	0xc0, 0xa8, 0x01, 0x64, 0x5d, 0xb8, 0xd8, 0x22, 0x94, 0x04, 0x00, 0x00,
+	0xc3, 0x50, 0x01, 0xbb, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x60, 0x02, 0xfa, 0xf0, 0xab, 0xcd, 0x00, 0x00, 0x02, 0x04, 0x05, 0xb4,
+}
+
+func ExampleDissect() {
+	parts, err := Dissect(Ethernet, syn)
+	for _, p := range parts {
+		fmt.Printf("%2d+%d %s\n", p.Off, p.Len, p.Line)
+	}
+	fmt.Println(err)
+
+	// Output:
+	//  0+14 ETH dst:00:1a:2b:3c:4d:5e src:52:54:00:12:34:56 type:0x0800
+	// 14+24 IPv4 ver:4 ihl:6 dscp:0 ecn:0 len:48 id:0x2000 DF frag:0 ttl:64 proto:6 sum:0x0000 src:192.168.1.100 dst:93.184.216.34
+	// 38+24 TCP sport:50000 dport:443 seq:4096 ack:0 off:6 cwr ece urg ack psh rst SYN fin win:64240 sum:0xABCD urg:0
+	// <nil>
+}
+
+// tlv is a type, length, value record where length counts the value only.
+var tlv = &Layer{
+	Header: &presets.Header{
+		Name: "TLV",
+		Len:  2,
+		Pic:  `'TLV type:'D.08@' len:'D.08@`,
+		Fields: []presets.Field{
+			{Name: "type", Bit: 0, Width: 8},
+			{Name: "len", Bit: 8, Width: 8},
+		},
+	},
+	Length: "len + 2",
+	Next:   "type",
+}
+
+func init() {
+	tlv.Over = map[uint64]*Layer{1: tlv, 2: tlv}
+}
+
+var dissectTests = []struct {
+	name  string
+	root  *Layer
+	data  []byte
+	parts string
+	err   string
+}{
+	{`tlv chain`, tlv, []byte{1, 1, 0xff, 2, 0, 3, 2, 0xaa, 0xbb},
+		`0+3 TLV type:1 len:1|3+2 TLV type:2 len:0|5+4 TLV type:3 len:2`, ``},
+	{`past data`, tlv, []byte{1, 9, 0xff},
+		`0+11 TLV type:1 len:9`, ``},
+	{`cut header`, Ethernet, syn[:20],
+		`0+14 ETH dst:00:1a:2b:3c:4d:5e src:52:54:00:12:34:56 type:0x0800|` +
+			`14+24 IPv4 ver:4 ihl:6 dscp:0 ecn:0 len:48 id:0x2000 frag:0 ttl:0 proto:0 sum:0x0000 src:0.0.0.0 dst:0.0.0.0`, ``},
+	{`short ihl`, IPv4, []byte{0x44},
+		`0+16 IPv4 ver:4 ihl:4 dscp:0 ecn:0 len:0 id:0x0000 frag:0 ttl:0 proto:0 sum:0x0000 src:0.0.0.0 dst:0.0.0.0`, ``},
+	{`fragment`, IPv4, append([]byte{0x45, 0, 0, 0x1c, 0x1c, 0x46, 0x00, 0xb9, 64, 17}, make([]byte, 18)...),
+		`0+20 IPv4 ver:4 ihl:5 dscp:0 ecn:0 len:28 id:0x1C46 frag:185 ttl:64 proto:17 sum:0x0000 src:0.0.0.0 dst:0.0.0.0`, ``},
+	{`first fragment`, IPv4, append([]byte{0x45, 0, 0, 0x1c, 0x1c, 0x46, 0x20, 0x00, 64, 17}, make([]byte, 18)...),
+		`0+20 IPv4 ver:4 ihl:5 dscp:0 ecn:0 len:28 id:0x1C46 MF frag:0 ttl:64 proto:17 sum:0x0000 src:0.0.0.0 dst:0.0.0.0|` +
+			`20+8 UDP sport:0 dport:0 len:0 sum:0x0000`, ``},
+	{`no data`, IPv4, nil, ``, ``},
+	{`bad length`, &Layer{Header: presets.UDP, Length: "len*x"}, []byte{1}, ``, `dissect: UDP: bad Length len*x`},
+	{`no length field`, &Layer{Header: presets.UDP, Length: "size"}, []byte{1}, ``, `dissect: UDP: bad Length size`},
+	{`no next field`, &Layer{Header: presets.UDP, Next: "proto"}, []byte{1},
+		`0+8 UDP sport:256 dport:0 len:0 sum:0x0000`, `dissect: UDP has no proto field`},
+	{`no stop field`, &Layer{Header: presets.UDP, Next: "len", Stop: "frag"}, []byte{1},
+		`0+8 UDP sport:256 dport:0 len:0 sum:0x0000`, `dissect: UDP has no frag field`},
+}
+
+func TestDissect(t *testing.T) {
+	for _, v := range dissectTests {
+		parts, err := Dissect(v.root, v.data)
+		var s []string
+		for _, p := range parts {
+			s = append(s, fmt.Sprintf("%d+%d %s", p.Off, p.Len, p.Line))
+		}
+		e := ""
+		if err != nil {
+			e = err.Error()
+		}
+		if o := strings.Join(s, "|"); o != v.parts || e != v.err {
+			t.Errorf("%s is broken! o≢e\n>%s< %s\n>%s< %s", v.name, o, e, v.parts, v.err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+// Func SnapBytes formats data with SnapN: data is cut into big-endian words
+// of eight bytes, word 0 is data[0:8], word 1 is data[8:16] and so on, up to
+// ten words. Short last word reads as a number made of the bytes it has.
+// Bytes past the tenth word are ignored.
+//
+//    bitpeek.SnapBytes(`'src:'D.16@' dst:'D.16@' len:'D.16@!16@#1' crc:'HHHH`,
+//      []byte{0x1f, 0x90, 0x00, 0x35, 0x00, 0x2a, 0x00, 0x00, 0x12, 0x34})
+//
+//    Output:
+//    src:8080 dst:53 len:42 crc:1234
+//
+func SnapBytes(pic string, data []byte) []byte {
+	var w [10]uint64
+	for i := 0; i < len(data) && i < 80; i++ {
+		w[i>>3] = w[i>>3]<<8 | uint64(data[i])
+	}
+	return SnapN(pic, w[:]...)
+}
+
+// DumpOpts tell Dump how to lay rows out. Zero value gives hexdump -C form.
+type DumpOpts struct {
+	Width int      // bytes in a row, 16 if 0
+	Base  int      // offset shown for data[0]
+	Pics  []string // Pics[i] annotates row i, "" for none
+}
+
+// Func Dump renders data the way `hexdump -C` does: offset, bytes in hex
+// with an extra space after every eight, then chars in |bars|. Char column
+// shows controls, DEL and C1 in the Escape style (set it to EscDot to get
+// a look of the real hexdump). Unlike A and C commands, Dump escapes DEL
+// and C1 under EscTilde too, so they show as ~. Rows that have a pic given
+// in opts.Pics get its SnapBytes of the row bytes in the next line, under
+// the hex column. Last line is the offset past data. Nil opts is the same
+// as zero DumpOpts.
+//
+//    bitpeek.Dump([]byte("\x1f\x90\x00\x35\x00\x2a\x12\x34Hello!\n"),
+//      &bitpeek.DumpOpts{Pics: []string{`'src:'D.16@' dst:'D.16@' len:'D.16@' crc:'HHHH`}})
+//
+//    Output:
+//    00000000  1f 90 00 35 00 2a 12 34  48 65 6c 6c 6f 21 0a     |~~~5~*~4Hello!~|
+//              src:8080 dst:53 len:42 crc:1234
+//    0000000f
+//
+func Dump(data []byte, opts *DumpOpts) []byte {
+	var o DumpOpts
+	if opts != nil {
+		o = *opts
+	}
+	if o.Width < 1 {
+		o.Width = 16
+	}
+	var b []byte
+	for r, at := 0, 0; at < len(data); r, at = r+1, at+o.Width {
+		row := data[at:]
+		if len(row) > o.Width {
+			row = row[:o.Width]
+		}
+		b = offset(b, o.Base+at)
+		b = append(b, ' ')
+		for i := 0; i < o.Width; i++ {
+			if i&7 == 0 {
+				b = append(b, ' ')
+			}
+			if i < len(row) {
+				b = append(b, hex[row[i]>>4], hex[row[i]&15], ' ')
+			} else {
+				b = append(b, "   "...)
+			}
+		}
+		b = append(b, ' ', '|')
+		var c [4]byte
+		for _, x := range row {
+			if x < 32 || x > 126 && x < 160 {
+				b = append(b, c[unprint(c[:], 4, x):]...)
+			} else {
+				b = append(b, x)
+			}
+		}
+		b = append(b, '|', '\n')
+		if r < len(o.Pics) && o.Pics[r] != "" {
+			b = append(b, "          "...)
+			b = append(b, SnapBytes(o.Pics[r], row)...)
+			b = append(b, '\n')
+		}
+	}
+	b = offset(b, o.Base+len(data))
+	return append(b, '\n')
+}
+
+const hex = "0123456789abcdef"
+
+// offset appends x as at least eight hex digits.
+func offset(b []byte, x int) []byte {
+	var d [16]byte
+	i := len(d)
+	for u := uint(x); u > 0 || i > len(d)-8; u >>= 4 {
+		i--
+		d[i] = hex[u&15]
+	}
+	return append(b, d[i:]...)
+}
@@ -0,0 +1,110 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExampleDump() {
+	defer func(e EscStyle) { Escape = e }(Escape)
+	Escape = EscDot
+	// UDP header, then payload
+	pkt := []byte("\x1f\x90\x00\x35\x00\x1d\x12\x34Hello, world!\n\x00\x01\x02")
+	fmt.Printf("%s", Dump(pkt, &DumpOpts{Pics: []string{
+		`'src:'D.16@' dst:'D.16@' len:'D.16@' crc:'HHHH#1' payload:'CCCCCCCC`,
+	}}))
+
+	// Output:
+	// 00000000  1f 90 00 35 00 1d 12 34  48 65 6c 6c 6f 2c 20 77  |...5...4Hello, w|
+	//           src:8080 dst:53 len:29 crc:1234 payload:Hello, w
+	// 00000010  6f 72 6c 64 21 0a 00 01  02                       |orld!....|
+	// 00000019
+}
+
+var bytesTests = []struct {
+	data []byte
+	name string
+	pic  string
+	out  string
+}{
+	//bitpeek:bytes:1
+	{[]byte{0xab, 0xcd}, `short word`, `HHHH`, `ABCD`},
+	//bitpeek:bytes:1
+	{[]byte{1, 2, 3, 4, 5, 6, 7, 8, 9}, `two words`, `HH#1HH`, `0809`},
+	//bitpeek:bytes:1
+	{[]byte{0x12, 0, 0, 0, 0, 0, 0, 0x34}, `big endian`, `HH!48@HH`, `1234`},
+	//bitpeek:bytes:1
+	{nil, `no data`, `HH#9HH`, `0000`},
+	//bitpeek:bytes:1
+	{counted(88), `eleven words`, `#9HHHHHHHHHHHHHHHH`, `48494A4B4C4D4E4F`},
+}
+
+// counted returns n bytes valued 0, 1, 2…
+func counted(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return b
+}
+
+var dumpTests = []struct {
+	data []byte
+	name string
+	opts *DumpOpts
+	out  string
+}{
+	//bitpeek:dump:1
+	{nil, `empty`, nil, "00000000\n"},
+	//bitpeek:dump:1
+	{[]byte("ABC"), `short`, nil,
+		"00000000  41 42 43                                          |ABC|\n" +
+			"00000003\n"},
+	//bitpeek:dump:1
+	{[]byte("0123456789abcdef"), `full row`, nil,
+		"00000000  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|\n" +
+			"00000010\n"},
+	//bitpeek:dump:1
+	{[]byte("0123456789"), `width 4 base`, &DumpOpts{Width: 4, Base: 0x1fffe},
+		"0001fffe  30 31 32 33  |0123|\n" +
+			"00020002  34 35 36 37  |4567|\n" +
+			"00020006  38 39        |89|\n" +
+			"00020008\n"},
+	//bitpeek:dump:1
+	{[]byte("0123456789"), `width 10`, &DumpOpts{Width: 10},
+		"00000000  30 31 32 33 34 35 36 37  38 39  |0123456789|\n" +
+			"0000000a\n"},
+	//bitpeek:dump:1
+	{[]byte("\x00\x7f\x9b\xe9"), `escapes`, nil,
+		"00000000  00 7f 9b e9                                       |~~~\xe9|\n" +
+			"00000004\n"},
+	//bitpeek:dump:1
+	{[]byte("0123456789"), `second row pic`, &DumpOpts{Width: 4, Pics: []string{``, `'n:'CCCC`}},
+		"00000000  30 31 32 33  |0123|\n" +
+			"00000004  34 35 36 37  |4567|\n" +
+			"          n:4567\n" +
+			"00000008  38 39        |89|\n" +
+			"0000000a\n"},
+}
+
+func TestSnapBytes(t *testing.T) {
+	for _, v := range bytesTests {
+		if o := string(SnapBytes(v.pic, v.data)); o != v.out {
+			t.Errorf("%s is broken! o≢e >%s< ≢ >%s<", v.name, o, v.out)
+		}
+	}
+}
+
+func TestDump(t *testing.T) {
+	for _, v := range dumpTests {
+		if o := string(Dump(v.data, v.opts)); o != v.out {
+			t.Errorf("%s is broken! o≢e\n%s≢\n%s", v.name, o, v.out)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package bitpeek
+
+// EscStyle tells how A and C commands show characters that can not be
+// printed: controls below 32, DEL and, for C, C1 controls 128 to 159.
+// Default EscTilde shows only controls below 32, as ~, and lets DEL and
+// C1 through, so UTF-8 made of C commands stays whole.
+type EscStyle byte
+
+const (
+	EscTilde   EscStyle = iota // ~ for controls below 32
+	EscDot                     // . as hexdumps show it
+	EscHex                     // \x1b
+	EscPicture                 // Unicode control pictures: ␀ ␛ ␡. C1 as \x9b
+)
+
+// Escape is the EscStyle of A and C commands. Set it once, before use.
+var Escape = EscTilde
+
+// escaped tells whether A or C shows c as Escape tells.
+func escaped(c byte) bool {
+	return c < 32 || Escape != EscTilde && c > 126 && c < 160
+}
+
+// unprint writes c that can not be printed as Escape tells. It fills ot
+// leftwards from oi and returns new oi. Room of 4 is needed.
+func unprint(ot []byte, oi int, c byte) int {
+	switch {
+	case Escape == EscDot:
+		oi--
+		ot[oi] = '.'
+	case Escape == EscPicture && c < 32:
+		return lput(ot, oi, []byte{0xe2, 0x90, 0x80 + c})
+	case Escape == EscPicture && c == 127:
+		return lput(ot, oi, []byte{0xe2, 0x90, 0xa1})
+	case Escape == EscHex, Escape == EscPicture:
+		return lput(ot, oi, []byte{'\\', 'x', "0123456789abcdef"[c>>4], "0123456789abcdef"[c&15]})
+	default:
+		oi--
+		ot[oi] = '~'
+	}
+	return oi
+}
@@ -0,0 +1,57 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExampleEscape() {
+	defer func(e EscStyle) { Escape = e }(Escape)
+	for _, e := range []EscStyle{EscTilde, EscDot, EscHex, EscPicture} {
+		Escape = e
+		fmt.Printf("%q\n", Snap(`CCCCCCCC`, 0x4f4b0a1b7f9b0021))
+	}
+
+	// Output:
+	// "OK~~\x7f\x9b~!"
+	// "OK.....!"
+	// "OK\\x0a\\x1b\\x7f\\x9b\\x00!"
+	// "OK␊␛␡\\x9b␀!"
+}
+
+func TestEscape(t *testing.T) {
+	defer func(e EscStyle) { Escape = e }(Escape)
+	for _, v := range []struct {
+		e   EscStyle
+		pic string
+		in  uint64
+		out string
+	}{
+		{EscTilde, `A`, 0x1b, `~`},
+		{EscTilde, `A`, 0x7f, "\x7f"},
+		{EscTilde, `A`, 0xff, "\x7f"},
+		{EscTilde, `A`, 0x7e, `~`},
+		{EscTilde, `C`, 0x9b, "\x9b"},
+		{EscTilde, `C`, 0xa0, "\xa0"},
+		{EscTilde, `CCC`, 0xe282ac, `€`}, // UTF-8 stays whole
+		{EscDot, `CCC`, 0xe282ac, "\xe2.\xac"},
+		{EscDot, `A'|'A`, 0xfa0, `.| `},
+		{EscHex, `C`, 0x80, `\x80`},
+		{EscHex, `A`, 0x00, `\x00`},
+		{EscPicture, `A`, 0x00, `␀`},
+		{EscPicture, `A`, 0x1f, `␟`},
+		{EscPicture, `C`, 0x9f, `\x9f`},
+		{EscPicture, `'x:'C{4}`, 0x01020304, `x:␁␂␃␄`},
+	} {
+		Escape = v.e
+		if o := string(Snap(v.pic, v.in)); o != v.out {
+			t.Errorf("Escape %d %s(%#x): got %q want %q", v.e, v.pic, v.in, o, v.out)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package bitpeek
+
+// nat is a fixed size natural number, little endian. It is big enough for
+// the float32 digits generation and it lives on the stack.
+type nat [8]uint32
+
+func (a *nat) set(x uint64) {
+	*a = nat{uint32(x), uint32(x >> 32)}
+}
+
+func (a *nat) shl(n uint) {
+	for ; n >= 32; n -= 32 {
+		copy(a[1:], a[:len(a)-1])
+		a[0] = 0
+	}
+	if n == 0 {
+		return
+	}
+	for i := len(a) - 1; i > 0; i-- {
+		a[i] = a[i]<<n | a[i-1]>>(32-n)
+	}
+	a[0] <<= n
+}
+
+func (a *nat) mul(m uint32) {
+	var c uint64
+	for i := range a {
+		c += uint64(a[i]) * uint64(m)
+		a[i] = uint32(c)
+		c >>= 32
+	}
+}
+
+func (a *nat) add(b *nat) {
+	var c uint64
+	for i := range a {
+		c += uint64(a[i]) + uint64(b[i])
+		a[i] = uint32(c)
+		c >>= 32
+	}
+}
+
+func (a *nat) sub(b *nat) {
+	var c uint64
+	for i := range a {
+		c = uint64(a[i]) - uint64(b[i]) - c
+		a[i] = uint32(c)
+		c >>= 63
+	}
+}
+
+func (a *nat) cmp(b *nat) int {
+	for i := len(a) - 1; i >= 0; i-- {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// float writes k bits of v, k is 16 or 32, as an IEEE-754 binary16 or
+// binary32 number. Digits are the shortest that read back to the same
+// value, the form is that of strconv's 'g' format with -1 precision. It
+// fills ot leftwards from oi and returns new oi. Room of 16 is needed.
+func float(ot []byte, oi int, v uint64, k uint) int {
+	mb, eb := uint(10), uint(5) // mantissa and exponent bits
+	if k == 32 {
+		mb, eb = 23, 8
+	}
+	m := v & (1<<mb - 1)
+	x := int(v>>mb) & (1<<eb - 1)
+	var b [24]byte // output, left to right
+	o := 0
+	if v>>(k-1)&1 == 1 {
+		b[o] = '-'
+		o++
+	}
+	switch {
+	case x == 1<<eb-1 && m != 0:
+		return lput(ot, oi, []byte("NaN"))
+	case x == 1<<eb-1 && o == 0:
+		return lput(ot, oi, []byte("+Inf"))
+	case x == 1<<eb-1:
+		return lput(ot, oi, []byte("-Inf"))
+	case x == 0 && m == 0:
+		b[o] = '0'
+		return lput(ot, oi, b[:o+1])
+	}
+	e := x - (1<<(eb-1) - 1) - int(mb) // exponent of m lsb
+	if x == 0 {
+		e++ // denormal
+	} else {
+		m |= 1 << mb
+	}
+
+	// Burger & Dybvig free-format digits. Value is r/s, halves of the gaps
+	// to neighbours are mp/s above and mm/s below. Bounds are inclusive for
+	// an even m as round-half-even reads them back to m.
+	var r, s, mp, mm, t nat
+	even := m&1 == 0
+	r.set(m)
+	s.set(2)
+	mp.set(1)
+	mm.set(1)
+	if e >= 0 {
+		r.shl(uint(e))
+		mp.shl(uint(e))
+		mm.shl(uint(e))
+	} else {
+		s.shl(uint(-e))
+	}
+	r.shl(1)
+	if x > 1 && m == 1<<mb { // lower neighbour is closer
+		r.shl(1)
+		s.shl(1)
+		mp.shl(1)
+	}
+	dp := 0 // value is 0.digits × 10^dp
+	for {
+		t = r
+		t.add(&mp)
+		if c := t.cmp(&s); c < 0 || c == 0 && !even {
+			break
+		}
+		s.mul(10)
+		dp++
+	}
+	for {
+		t = r
+		t.add(&mp)
+		t.mul(10)
+		if c := t.cmp(&s); c > 0 || c == 0 && even {
+			break
+		}
+		r.mul(10)
+		mp.mul(10)
+		mm.mul(10)
+		dp--
+	}
+	var d [10]byte
+	nd := 0
+	for {
+		r.mul(10)
+		mp.mul(10)
+		mm.mul(10)
+		q := byte(0)
+		for r.cmp(&s) >= 0 {
+			r.sub(&s)
+			q++
+		}
+		c := r.cmp(&mm)
+		lo := c < 0 || c == 0 && even
+		t = r
+		t.add(&mp)
+		c = t.cmp(&s)
+		hi := c > 0 || c == 0 && even
+		switch {
+		case !lo && !hi:
+			d[nd] = q
+			nd++
+			continue
+		case lo && hi: // closer one
+			t = r
+			t.shl(1)
+			if c := t.cmp(&s); c > 0 || c == 0 && q&1 == 1 {
+				q++
+			}
+		case hi:
+			q++
+		}
+		d[nd] = q
+		nd++
+		break
+	}
+
+	if x := dp - 1; x < -4 || x >= 6 { // %e
+		b[o] = '0' + d[0]
+		o++
+		if nd > 1 {
+			b[o] = '.'
+			o++
+			for i := 1; i < nd; i++ {
+				b[o] = '0' + d[i]
+				o++
+			}
+		}
+		b[o] = 'e'
+		b[o+1] = '+'
+		if x < 0 {
+			b[o+1] = '-'
+			x = -x
+		}
+		o += 2
+		if x >= 10 {
+			b[o] = byte('0' + x/10)
+			o++
+		} else {
+			b[o] = '0'
+			o++
+		}
+		b[o] = byte('0' + x%10)
+		return lput(ot, oi, b[:o+1])
+	}
+	if dp <= 0 { // %f
+		b[o] = '0'
+		b[o+1] = '.'
+		o += 2
+		for ; dp < 0; dp++ {
+			b[o] = '0'
+			o++
+		}
+		dp = -1
+	}
+	for i := 0; i < nd || i < dp; i++ {
+		if i == dp {
+			b[o] = '.'
+			o++
+		}
+		b[o] = '0'
+		if i < nd {
+			b[o] += d[i]
+		}
+		o++
+	}
+	return lput(ot, oi, b[:o])
+}
+
+// lput puts s on the left of oi and returns new oi.
+func lput(ot []byte, oi int, s []byte) int {
+	oi -= len(s)
+	copy(ot[oi:], s)
+	return oi
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package bitpeek
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func snapFloat(v uint64, k uint) string {
+	ot := make([]byte, 16)
+	return string(ot[float(ot, 16, v, k):])
+}
+
+func TestFloat32(t *testing.T) {
+	vals := []uint32{0, 1, 0x80000000, 0x7f7fffff, 0x00800000, 0x007fffff,
+		0x3f800000, 0x3dcccccd, 0x7f800000, 0xff800000, 0x7fc00000, 0x4b800000,
+		0x49742400, 0x497423ff, 0x38d1b717, 0x501502f9}
+	x := uint32(0x9e3779b9)
+	for i := 0; i < 1<<18; i++ { // xorshift
+		x ^= x << 13
+		x ^= x >> 17
+		x ^= x << 5
+		vals = append(vals, x)
+	}
+	for _, v := range vals {
+		want := strconv.FormatFloat(float64(math.Float32frombits(v)), 'g', -1, 32)
+		if o := snapFloat(uint64(v), 32); o != want {
+			t.Fatalf("%#08x: got %s want %s", v, o, want)
+		}
+	}
+}
+
+// half returns binary16 h as a float64.
+func half(h uint16) float64 {
+	m, x := float64(h&0x3ff), int(h>>10&0x1f)
+	f := math.Ldexp(m, -24)
+	switch x {
+	case 0:
+	case 0x1f:
+		f = math.Inf(1)
+		if m != 0 {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(m+1024, x-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
+
+// toHalf rounds positive f to the nearest binary16, ties to even.
+func toHalf(f float64) uint16 {
+	lo, hi := 0, 0x7c00 // half(lo) <= f < half(hi)
+	for hi-lo > 1 {
+		m := (lo + hi) / 2
+		if half(uint16(m)) <= f {
+			lo = m
+		} else {
+			hi = m
+		}
+	}
+	b, c := f-half(uint16(lo)), half(uint16(lo+1))-f
+	if hi == 0x7c00 {
+		c = 65520 - f // halfway to the next, Inf
+	}
+	if c < b || c == b && lo&1 == 1 {
+		return uint16(lo + 1)
+	}
+	return uint16(lo)
+}
+
+// digits counts significant digits in a formatted number.
+func digits(s string) int {
+	var d []byte
+	for i := 0; i < len(s) && s[i] != 'e'; i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			d = append(d, s[i])
+		}
+	}
+	return len(strings.Trim(string(d), "0"))
+}
+
+func TestFloat16(t *testing.T) {
+	for v := 0; v < 1<<16; v++ {
+		h := uint16(v)
+		o := snapFloat(uint64(v), 16)
+		f := half(h)
+		switch {
+		case math.IsNaN(f):
+			if o != "NaN" {
+				t.Errorf("%#04x: got %s want NaN", v, o)
+			}
+			continue
+		case math.IsInf(f, 0):
+			if want := strconv.FormatFloat(f, 'g', -1, 64); o != want {
+				t.Errorf("%#04x: got %s want %s", v, o, want)
+			}
+			continue
+		}
+		g, err := strconv.ParseFloat(o, 64)
+		if err != nil || toHalf(math.Abs(g)) != h&0x7fff || math.Signbit(g) != (h>>15 == 1) {
+			t.Errorf("%#04x: %s does not read back: %v", v, o, err)
+			continue
+		}
+		for n := 1; n < digits(o); n++ { // nothing shorter reads back
+			s := strconv.FormatFloat(math.Abs(f), 'e', n-1, 64)
+			if g, _ := strconv.ParseFloat(s, 64); toHalf(g) == h&0x7fff {
+				t.Errorf("%#04x: got %s but %s is shorter", v, o, s)
+				break
+			}
+		}
+	}
+}
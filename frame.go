@@ -0,0 +1,77 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package bitpeek
+
+// CutMark replaces the last column of a row that SnapRows had to cut. Set
+// it once, before use. Zero CutMark leaves rows as cut.
+var CutMark byte = '>'
+
+// Func SnapRows renders pic for from into rows of a character display, eg.
+// a [2][16]byte frame of a 16x2 LCD. Line breaks (\n) of the output start
+// a new row, short rows are padded with spaces, rows with no output left
+// are blanked. Row that is too long is cut and gets CutMark at its end,
+// as does the last row if output has more lines than there are rows. It
+// returns true if anything was cut.
+//
+//    var lcd [2][16]byte
+//    bitpeek.SnapRows([][]byte{lcd[0][:], lcd[1][:]},
+//      `'Temp:'Q04.1.12@' C\n''Fan>`, 0x32f)
+//
+//    lcd[0] "Temp:25.4 C     "
+//    lcd[1] "Fan             "
+//
+// Columns are bytes, so pics for displays should stick to their charset.
+// SnapRows makes no garbage if pic and its output are under 256 bytes.
+func SnapRows(rows [][]byte, pic string, from uint64) bool {
+	var b [256]byte
+	cut := false
+	r, c := 0, 0
+	for _, x := range snapb(&b, pic, from) {
+		switch {
+		case x == '\n':
+			if r < len(rows) && c < len(rows[r]) {
+				pad(rows[r][c:])
+			}
+			r, c = r+1, 0
+		case r >= len(rows):
+			if len(rows) > 0 {
+				mark(rows[len(rows)-1])
+			}
+			return true
+		case c < len(rows[r]):
+			rows[r][c] = x
+			c++
+		case c == len(rows[r]): // cut the rest of line
+			mark(rows[r])
+			cut = true
+			c++
+		}
+	}
+	for ; r < len(rows); r, c = r+1, 0 {
+		if c < len(rows[r]) {
+			pad(rows[r][c:])
+		}
+	}
+	return cut
+}
+
+// Func SnapLine is SnapRows for a one row display.
+func SnapLine(line []byte, pic string, from uint64) bool {
+	return SnapRows([][]byte{line}, pic, from)
+}
+
+// pad fills row with spaces.
+func pad(row []byte) {
+	for i := range row {
+		row[i] = ' '
+	}
+}
+
+// mark puts CutMark at the end of row.
+func mark(row []byte) {
+	if CutMark != 0 && len(row) > 0 {
+		row[len(row)-1] = CutMark
+	}
+}
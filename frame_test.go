@@ -0,0 +1,95 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package bitpeek
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func ExampleSnapRows() {
+	var lcd [2][16]byte
+	rows := [][]byte{lcd[0][:], lcd[1][:]}
+	for _, v := range []uint64{0x32f, 0x1e3e} {
+		cut := SnapRows(rows, `'Temp:'Q04.1.12@' C\n''Fan>`, v)
+		fmt.Printf("|%s|\n|%s| %v\n", lcd[0][:], lcd[1][:], cut)
+	}
+
+	// Output:
+	// |Temp:25.4 C     |
+	// |Fan             | false
+	// |Temp:-14.1 C    |
+	// |                | false
+}
+
+var rowsTests = []struct {
+	name string
+	rows []int // row widths
+	pic  string
+	inp  uint64
+	out  string // rows joined by |
+	cut  bool
+}{
+	//bitpeek:rows:1
+	{`fits`, []int{8, 8}, `'ab\ncd'`, 0, `ab      |cd      `, false},
+	//bitpeek:rows:1
+	{`blank rows`, []int{4, 4, 4}, `'ab'`, 0, `ab  |    |    `, false},
+	//bitpeek:rows:1
+	{`long row`, []int{4, 4}, `'abcdefg\nhi'`, 0, `abc>|hi  `, true},
+	//bitpeek:rows:1
+	{`exact row`, []int{4}, `'abcd'`, 0, `abcd`, false},
+	//bitpeek:rows:1
+	{`more lines`, []int{3, 3}, `'a\nb\nc'`, 0, `a  |b >`, true},
+	//bitpeek:rows:1
+	{`trailing NL`, []int{3, 3}, `'a\nb\n'`, 0, `a  |b  `, false},
+	//bitpeek:rows:1
+	{`empty lines`, []int{2, 2}, `'\n\nx'`, 0, `  | >`, true},
+	//bitpeek:rows:1
+	{`decimal`, []int{5}, `D.......32@`, 0xffffffff, `4294>`, true},
+	//bitpeek:rows:1
+	{`empty row`, []int{0, 2}, `'ab\ncd'`, 0, `|cd`, true},
+	//bitpeek:rows:1
+	{`no rows`, nil, `'ab'`, 0, ``, true},
+	//bitpeek:rows:1
+	{`no output`, []int{2}, `!08@`, 0, `  `, false},
+}
+
+func TestSnapRows(t *testing.T) {
+	for _, v := range rowsTests {
+		var rows [][]byte
+		for _, n := range v.rows {
+			rows = append(rows, []byte(strings.Repeat("#", n)))
+		}
+		cut := SnapRows(rows, v.pic, v.inp)
+		var o []string
+		for _, r := range rows {
+			o = append(o, string(r))
+		}
+		if s := strings.Join(o, "|"); s != v.out || cut != v.cut {
+			t.Errorf("%s is broken! o≢e >%s< %v ≢ >%s< %v", v.name, s, cut, v.out, v.cut)
+		}
+	}
+}
+
+func TestSnapLine(t *testing.T) {
+	defer func(m byte) { CutMark = m }(CutMark)
+	CutMark = 0
+	var line [8]byte
+	if cut := SnapLine(line[:], `'Id:0x'X.32@`, 0xdeadbeef); string(line[:]) != `Id:0xDEA` || !cut {
+		t.Errorf("SnapLine is broken! o≢e >%s< %v ≢ >Id:0xDEA< true", line[:], cut)
+	}
+}
+
+func TestSnapRowsAllocs(t *testing.T) {
+	var lcd [4][20]byte
+	rows := [][]byte{lcd[0][:], lcd[1][:], lcd[2][:], lcd[3][:]}
+	if n := testing.AllocsPerRun(100, func() {
+		SnapRows(rows, `'T:'Ts0.32@'\nIP:'IPv4.Address32@`, 0x5c0a1b2c3d4e5f60)
+		SnapLine(lcd[0][:], `'Id:0x'X.32@`, 0xdeadbeef)
+	}); n != 0 {
+		t.Errorf("SnapRows makes %v allocations", n)
+	}
+}
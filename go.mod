@@ -1 +1,3 @@
 module github.com/ohir/bitpeek
+
+go 1.18
@@ -0,0 +1,62 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package format holds number formatters of bitpeek commands that are too
+// long to sit in the parser. They fill output leftwards, as Snap does.
+package format
+
+// Fixed writes k bits of v as a fixed point number with f fraction bits,
+// rounded to p decimal places. Q numbers are two's complement signed. It
+// fills ot leftwards from oi and returns new oi. Room of 22+p is needed.
+func Fixed(ot []byte, oi int, v uint64, k, f, p uint, signed bool) int {
+	m := uint64(0xFFFFffffFFFFffff) >> (64 - k)
+	v &= m
+	neg := signed && v>>(k-1) == 1
+	if neg {
+		v = -v & m
+	}
+	i, x := v>>f, v&(1<<f-1)
+	if f > 59 { // x*10 must fit
+		x >>= f - 59
+		f = 59
+	}
+	var d [9]byte
+	for j := uint(0); j < p; j++ {
+		x *= 10
+		d[j] = byte(x >> f)
+		x &= 1<<f - 1
+	}
+	if f > 0 && x >= 1<<(f-1) { // round half up
+		j := int(p) - 1
+		for ; j >= 0 && d[j] == 9; j-- {
+			d[j] = 0
+		}
+		if j < 0 {
+			i++
+		} else {
+			d[j]++
+		}
+	}
+	for j := int(p) - 1; j >= 0; j-- {
+		oi--
+		ot[oi] = 48 + d[j]
+	}
+	if p > 0 {
+		oi--
+		ot[oi] = '.'
+	}
+	for i > 9 {
+		k := i / 10
+		oi--
+		ot[oi] = byte(48 + i - k*10)
+		i = k
+	}
+	oi--
+	ot[oi] = byte(48 + i)
+	if neg {
+		oi--
+		ot[oi] = '-'
+	}
+	return oi
+}
@@ -0,0 +1,26 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package format
+
+import "testing"
+
+func TestFixed(t *testing.T) {
+	for _, v := range []struct {
+		v       uint64
+		k, f, p uint
+		signed  bool
+		out     string
+	}{
+		{0x32f, 12, 4, 1, false, `50.9`},
+		{0xfff, 12, 4, 1, true, `-0.1`},
+		{0xfff, 12, 4, 0, false, `256`},
+		{0x80, 8, 0, 2, true, `-128.00`},
+	} {
+		var ot [40]byte
+		if o := string(ot[Fixed(ot[:], len(ot), v.v, v.k, v.f, v.p, v.signed):]); o != v.out {
+			t.Errorf("Fixed(%#x, %d, %d, %d, %v) got %s want %s", v.v, v.k, v.f, v.p, v.signed, o, v.out)
+		}
+	}
+}
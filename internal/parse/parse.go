@@ -0,0 +1,145 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package parse holds pieces of the picstring parser that both bitpeek and
+// bitpeekgen need: finding blocks, their variants and repeats. Pics are read
+// right to left, as Snap reads them.
+package parse
+
+// Block returns index j of the [ that opens a block ended with ] right after
+// pic and index v of its last variant start: either | or j for a block with
+// one variant. Index j is > 0 as [ is glued to a > or < (conditional block)
+// or to a selector command. Selector width is returned in sw, > and < have
+// it 0. If there is no such [, Block returns -1.
+func Block(pic string) (j, v int, sw uint) {
+	d := 0 // nesting
+	v = -1
+	for i := len(pic) - 1; i > 0; i-- {
+		switch {
+		case pic[i-1] == '\\':
+			i--
+		case pic[i] == ']':
+			d++
+		case pic[i] == '|':
+			if d == 0 && v < 0 {
+				v = i
+			}
+		case pic[i] != '[':
+		case d > 0:
+			d--
+		case i > 1 && pic[i-2] == '\\' && pic[i-1] != '@':
+			return -1, -1, 0
+		case pic[i-1] == '>' || pic[i-1] == '<':
+			return i, i, 0
+		default:
+			if sw = Selw(pic[:i]); sw == 0 {
+				return -1, -1, 0
+			}
+			if v < 0 {
+				v = i
+			}
+			return i, v, sw
+		}
+	}
+	return -1, -1, 0
+}
+
+// Selw returns width of the selector command that ends pic. Zero if pic does
+// not end with a command that could select a variant.
+func Selw(pic string) uint {
+	i := len(pic) - 1
+	switch pic[i] {
+	case 'B':
+		return 1
+	case 'E':
+		return 2
+	case 'F':
+		return 3
+	case 'G':
+		return 5
+	case 'A':
+		return 7
+	case 'C':
+		return 8
+	case 'H':
+		n := uint(4)
+		for ; i > 0 && pic[i-1] == 'H'; i-- {
+			n += 4
+		}
+		return n
+	case '@':
+		if i > 3 && pic[i-2]-48 < 10 && pic[i-1]-48 < 10 {
+			if k := uint(pic[i-2]-48)*10 + uint(pic[i-1]-48); k <= 64 {
+				return k
+			}
+		}
+	}
+	return 0
+}
+
+// Variant returns indices of | or [ and | or ] that surround variant n of
+// the switch block opened at j and closed at len(pic). Both are -1 if block
+// has no such variant.
+func Variant(pic string, j int, n uint64) (l, r int) {
+	d := 0
+	l = j
+	for i := j + 1; i <= len(pic); i++ {
+		if i == len(pic) {
+			if n == 0 {
+				return l, i
+			}
+			break
+		}
+		switch {
+		case pic[i-1] == '\\':
+		case pic[i] == '[':
+			d++
+		case pic[i] == ']':
+			d--
+		case pic[i] == '|' && d == 0:
+			if n == 0 {
+				return l, i
+			}
+			n--
+			l = i
+		}
+	}
+	return -1, -1
+}
+
+// Repeat parses {n}, {n/g} or {n/gS} that ends pic. It returns index j of
+// the { and count n. Group g is 0 if there is no /g. Separator s is a space
+// if not given. Count n is 0 if there is no valid repeat of a BEFHGAC or @
+// command.
+func Repeat(pic string) (j, n, g int, s byte) {
+	j, s = len(pic)-1, ' '
+	if j > 0 && pic[j]-48 > 9 && pic[j] != '/' {
+		s = pic[j]
+		j--
+	}
+	for k := 1; j > 0 && pic[j]-48 < 10; j-- {
+		g += k * int(pic[j]-48)
+		k *= 10
+	}
+	if j > 0 && pic[j] == '/' {
+		j--
+		for k := 1; j > 0 && pic[j]-48 < 10; j-- {
+			n += k * int(pic[j]-48)
+			k *= 10
+		}
+	} else if s == ' ' {
+		n, g = g, 0
+	} else {
+		return -1, 0, 0, 0
+	}
+	if j < 1 || pic[j] != '{' || n < 1 || n > 999 || g >= n ||
+		j > 1 && pic[j-2] == '\\' {
+		return -1, 0, 0, 0
+	}
+	switch pic[j-1] {
+	case 'B', 'E', 'F', 'H', 'G', 'A', 'C', '@':
+		return j, n, g, s
+	}
+	return -1, 0, 0, 0
+}
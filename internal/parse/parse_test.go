@@ -0,0 +1,62 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package parse
+
+import "testing"
+
+func TestBlock(t *testing.T) {
+	for _, v := range []struct {
+		pic  string
+		j, v int
+		sw   uint
+	}{
+		{`'X>[ xx`, 3, 3, 0},
+		{`F[ a| b`, 1, 4, 3},
+		{`HH[ a|B[ b| c]| d`, 2, 14, 8},
+		{`[ a`, -1, -1, 0},
+		{`'x\[ a`, -1, -1, 0},
+	} {
+		if j, l, sw := Block(v.pic); j != v.j || l != v.v || sw != v.sw {
+			t.Errorf("Block(%q) got %d %d %d want %d %d %d", v.pic, j, l, sw, v.j, v.v, v.sw)
+		}
+	}
+}
+
+func TestVariant(t *testing.T) {
+	pic := `F[ a|B[ b| c]| d`
+	for _, v := range []struct {
+		n    uint64
+		l, r int
+	}{
+		{0, 1, 4},
+		{1, 4, 13},
+		{2, 13, len(pic)},
+		{3, -1, -1},
+	} {
+		if l, r := Variant(pic, 1, v.n); l != v.l || r != v.r {
+			t.Errorf("Variant %d got %d %d want %d %d", v.n, l, r, v.l, v.r)
+		}
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	for _, v := range []struct {
+		pic     string
+		j, n, g int
+		s       byte
+	}{
+		{`B{4`, 1, 4, 0, ' '},
+		{`B{8/4`, 1, 8, 4, ' '},
+		{`HH{12/4:`, 2, 12, 4, ':'},
+		{`x{4`, -1, 0, 0, 0},
+		{`B{4/4`, -1, 0, 0, 0},
+		{`B{0`, -1, 0, 0, 0},
+	} {
+		if j, n, g, s := Repeat(v.pic); j != v.j || n != v.n || g != v.g || s != v.s {
+			t.Errorf("Repeat(%q) got %d %d %d %q want %d %d %d %q",
+				v.pic, j, n, g, s, v.j, v.n, v.g, v.s)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+import "github.com/ohir/bitpeek/internal/parse"
+
+// Func SnapN formats a record made of many words with a single picstring.
+// A #d command, where d is a digit, switches the source of bits: everything
+// on the right of #d takes bits from words[d]. Pic on the left of the first
+// #d reads words[0]. Each word keeps its own bit position, so coming back to
+// a word continues where its previous part of pic stopped. Up to ten words
+// can be used, words not given read as zeros.
+//
+//    bitpeek.SnapN(`'Type:'F 'EXT=.ACK= Id:0xFHH #1 'Status:' 'OVL=.ERR=.RDY= crc:HH`,
+//      0xafdf, 0x15a)
+//
+//    Output:
+//    Type:5 ext.ACK Id:0x7DF  Status: ovl.err.RDY crc:5A
+//
+// A #d in a block variant reaches the end of that variant only. Part of the
+// variant on the left of its first #d, and pic on the right of the block,
+// read the word the block starts in.
+//
+// Word switch is always interpreted, even in quoted text and labels, same as
+// \n and \t escapes are. Use \# for the literal # followed by a digit. Plain
+// Snap does not know of word switches and outputs #d as is.
+func SnapN(pic string, words ...uint64) []byte {
+	p := peek{multi: true}
+	copy(p.words[:], words)
+	p.cur = word(pic, 0)
+	ot, oi := snap(pic, p.words[p.cur], make([]byte, len(pic)), len(pic), &p)
+	return ot[oi:]
+}
+
+// word returns the digit of the rightmost #d word switch in pic, or d if
+// there is none. Switches inside blocks of pic are not seen.
+func word(pic string, d int) int {
+	for i := len(pic) - 1; i > 0; i-- {
+		switch {
+		case pic[i] == ']' && pic[i-1] != '\\':
+			if j, _, _ := parse.Block(pic[:i]); j > 0 {
+				i = j
+			}
+		case pic[i]-48 < 10 && pic[i-1] == '#' && (i < 2 || pic[i-2] != '\\'):
+			return int(pic[i] - 48)
+		}
+	}
+	return d
+}
+
+// to switches to the word w. It keeps from as what is left of the word in
+// use, and returns what is left of w.
+func (p *peek) to(w int, from uint64) uint64 {
+	p.words[p.cur] = from
+	p.cur = w
+	return p.words[w]
+}
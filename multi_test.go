@@ -0,0 +1,84 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExampleSnapN() {
+	// Frame is a header word followed by a status word
+	var header, status uint64 = 0xafdf, 0x15a
+
+	fmt.Printf("%s\n", SnapN(
+		`'Type:'F 'EXT=.ACK= Id:0xFHH #1 'Status:' 'OVL=.ERR=.RDY= crc:HH`,
+		header, status))
+
+	// Output:
+	// Type:5 ext.ACK Id:0x7DF  Status: ovl.err.RDY crc:5A
+}
+
+var multiTests = []struct {
+	words []uint64
+	name  string
+	pic   string
+	out   string
+}{
+	//bitpeek:multi:1
+	{[]uint64{0xab, 0xcd}, `two words`, `HH#1HH`, `ABCD`},
+	//bitpeek:multi:1
+	{[]uint64{0xab, 0xcd}, `swapped`, `HH#1HH#0HH`, `00CDAB`},
+	//bitpeek:multi:1
+	{[]uint64{0xab, 0xcd, 0xef}, `back to word`, `H#2HH#1H#0H`, `AEFDB`},
+	//bitpeek:multi:1
+	{[]uint64{0xab}, `missing word`, `HH#7HH`, `AB00`},
+	//bitpeek:multi:1
+	{[]uint64{0xab, 0xcd}, `in quotes`, `'w0:#0HH w1:#1HH' #0HH#1HH`, `w0:HH w1:HH ABCD`},
+	//bitpeek:multi:1
+	{[]uint64{0xab, 0xcd}, `escaped`, `\#1:HH \\#1:HH`, `#1:00 \#1:AB`},
+	//bitpeek:multi:1
+	{[]uint64{0x1, 0x2}, `labels`, `'ONE= #1'TWO= ONE=`, `ONE TWO one`},
+	//bitpeek:multi:1
+	{nil, `no words`, `#5HH`, `00`},
+	//bitpeek:multi:1
+	{[]uint64{0x0, 0xab}, `switch in other variant`, `B[ a:H| b:#1H]`, `0 a:0`},
+	//bitpeek:multi:1
+	{[]uint64{0x1234, 0xabcd}, `switch in variants`, `B[ a:#1H| b:#1HH]`, `0 a:D`},
+	//bitpeek:multi:1
+	{[]uint64{0x1, 0xabcd}, `switch in last variant`, `B[ a:#1H| b:#1HH]`, `1 b:CD`},
+	//bitpeek:multi:1
+	{[]uint64{0x1, 0xcd}, `switch after block`, `B[ a| b] #1HH`, `1 b CD`},
+	//bitpeek:multi:1
+	{[]uint64{0x12, 0xab}, `block keeps its word`, `B[ a:#1H| b:#1H] H`, `1 b:B 2`},
+	//bitpeek:multi:1
+	{[]uint64{0x13, 0xcd}, `in conditional`, `>[#1HH]H`, `CD3`},
+	//bitpeek:multi:1
+	{[]uint64{0x23, 0xcd}, `hidden conditional`, `>[#1HH]H`, `3`},
+	//bitpeek:multi:1
+	{[]uint64{0x1, 0x5, 0xcd}, `nested`, `E[ a| b:#1B[ x| y:#2HH]| c]`, `1 b:1 y:CD`},
+	//bitpeek:multi:1
+	{[]uint64{0x1, 0x4, 0xcd}, `nested redo`, `E[ a| b:#1B[ x:#2H| y:#2HH]| c]`, `1 b:0 x:D`},
+	//bitpeek:multi:1
+	{[]uint64{21, ^uint64(21)}, `command reads past variant`, `#0A#B[Cx@]00`, `~#01[010100`},
+}
+
+func TestSnapN(t *testing.T) {
+	for _, v := range multiTests {
+		if o := string(SnapN(v.pic, v.words...)); o != v.out {
+			t.Errorf("%s is broken! o≢e >%s< ≢ >%s<", v.name, o, v.out)
+		}
+	}
+	for _, v := range parseTests { // one word is Snap
+		if o := string(SnapN(v.pic, v.inp)); o != v.out {
+			t.Errorf("SnapN %s is broken! o≢e >%s< ≢ >%s<", v.name, o, v.out)
+		}
+	}
+	if o := string(Snap(`Id #1 HH`, 0xab)); o != `Id #1 AB` {
+		t.Errorf("Snap interpreted #1 word switch: >%s<", o)
+	}
+}
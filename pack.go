@@ -0,0 +1,130 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+// Func Pack is the reverse of Snap: it builds the value that Snap would show
+// with fields set as given. So one pic defines both how a header is decoded
+// and how tests make one. Field is a command (or a label) with the text on
+// its left up to the previous command, as in SnapDiff. Commands glued with no
+// text between them make one field, so Id in 'Id:0xFHH' is 11 bits wide.
+// Name of a field is the first word of its text: `'Type:'F` is Type, `.ACK=`
+// is ACK. Words start with a letter or _ and go on with letters, digits
+// and _. Fields with no name and !dd@ skips stay zero.
+//
+//    bitpeek.Pack(`'Type:'F 'EXT=.ACK= Id:0xFHH`,
+//      map[string]uint64{"Type": 5, "ACK": 1, "Id": 0x7df})
+//
+//    Output:
+//    0xafdf <nil>
+//
+// Every fields key must name a field of pic and its value must fit the
+// field width. Negative numbers cast to uint64 fit if their two's complement
+// does, so int64 -3 packs to 101 in 3 bits. If a name is used by many
+// fields, all of them are set. Pack can not fill switch blocks, it fills
+// conditional ones regardless of their bit. Returned error, if any, is
+// a *PicError.
+func Pack(pic string, fields map[string]uint64) (uint64, error) {
+	if err := Check(pic); err != nil {
+		return 0, err
+	}
+	p := &peek{pack: true, pic: pic}
+	snap(pic, 0, make([]byte, len(pic)), len(pic), p)
+	if p.err != nil {
+		return 0, p.err
+	}
+	var u uint64
+	for i := len(p.fields) - 1; i >= 0; i-- { // left to right
+		f := &p.fields[i]
+		x, ok := fields[f.name]
+		if !ok {
+			continue
+		}
+		if f.w < 64 {
+			if x>>f.w != 0 && int64(x)>>(f.w-1) != -1 {
+				return 0, &PicError{f.at, "value does not fit " + f.name}
+			}
+			x &= 1<<f.w - 1
+		}
+		if f.lo < 64 {
+			u |= x << f.lo
+		}
+	}
+	for name := range fields {
+		if !p.has(name) {
+			return 0, &PicError{len(pic), "no field named " + name}
+		}
+	}
+	return u, nil
+}
+
+// field closes the field of the command that started at p.cs. Its text ends
+// at pi, on the right of the next command w. Field with no text is glued to
+// the next one, fields of skips and with no name are forgotten.
+func (p *peek) field(pi int, nb uint, w byte) {
+	if nb == p.fb { // no field open
+		return
+	}
+	lo := pi + 1
+	if w == 0 {
+		lo = 0
+	}
+	var text string
+	if lo < p.cs {
+		text = p.pic[lo:p.cs]
+	}
+	switch {
+	case p.pic[p.cs] == '!':
+	case text == "" && w != 0:
+		return // keep p.fb
+	default:
+		if name := first(text); name != "" {
+			p.fields = append(p.fields, pfield{name, p.cs, p.fb, nb - p.fb})
+		}
+	}
+	p.fb = nb
+}
+
+// has tells whether p found a field named name.
+func (p *peek) has(name string) bool {
+	for _, f := range p.fields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// first returns the first word of text: letters, digits and _ where the
+// first one is not a digit. Escaped characters are not part of any word.
+func first(text string) string {
+	for i := 0; i < len(text); {
+		c := text[i]
+		if c == '\\' {
+			i += 2
+			continue
+		}
+		j := i
+		for j < len(text) && (letter(text[j]) || text[j]-48 < 10) {
+			j++
+		}
+		switch {
+		case j == i:
+			i++
+		case c-48 < 10:
+			i = j
+		default:
+			return text[i:j]
+		}
+	}
+	return ""
+}
+
+// letter tells whether c is a letter of a word. Bytes of UTF-8 sequences
+// are.
+func letter(c byte) bool {
+	return c|0x20-'a' < 26 || c == '_' || c >= 0x80
+}
@@ -0,0 +1,97 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExamplePack() {
+	pic := `'Type:'F 'EXT=.ACK= Id:0xFHH`
+	v, err := Pack(pic, map[string]uint64{"Type": 5, "ACK": 1, "Id": 0x7df})
+	fmt.Printf("%#x %v\n", v, err)
+	fmt.Printf("%s\n", Snap(pic, v))
+
+	// Output:
+	// 0xafdf <nil>
+	// Type:5 ext.ACK Id:0x7DF
+}
+
+var packTests = []struct {
+	name   string
+	pic    string
+	fields map[string]uint64
+	out    uint64
+	err    string
+}{
+	//bitpeek:pack:1
+	{`header`, `'Type:'F 'EXT=.ACK= Id:0xFHH from IPv4.Address32@:D.16@`,
+		map[string]uint64{"Type": 5, "EXT": 0, "ACK": 1, "Id": 0x7df, "from": 0xdeadbeef},
+		0xafdfdeadbeef0000, ``},
+	//bitpeek:pack:1
+	{`skip`, `'Type:'F 'EXT=.ACK= Id:0xFHH!48@`,
+		map[string]uint64{"Id": 0x7df}, 0x07df000000000000, ``},
+	//bitpeek:pack:1
+	{`repeat`, `'Flags:'B{8/4} x:D.04@`,
+		map[string]uint64{"Flags": 0xa5, "x": 3}, 0xa53, ``},
+	//bitpeek:pack:1
+	{`block`, `'EXT>[ Id:HH] crc:HH`,
+		map[string]uint64{"EXT": 1, "Id": 0xab, "crc": 0xcd}, 0x1abcd, ``},
+	//bitpeek:pack:1
+	{`hidden block`, `'EXT>[ Id:HH] crc:HH`,
+		map[string]uint64{"Id": 0xab}, 0xab00, ``},
+	//bitpeek:pack:1
+	{`escapes`, `\nX:HH\tY:HH`,
+		map[string]uint64{"X": 1, "Y": 2}, 0x102, ``},
+	//bitpeek:pack:1
+	{`first word`, `'Port no.:'D.16@`,
+		map[string]uint64{"Port": 80}, 80, ``},
+	//bitpeek:pack:1
+	{`twice`, `'a:'H' b:'H' a:'H`,
+		map[string]uint64{"a": 7}, 0x707, ``},
+	//bitpeek:pack:1
+	{`signed`, `'T:'Q00.0.08@' n:'E`,
+		map[string]uint64{"T": 0xfffffffffffffffb, "n": 1}, 0x3ed, ``},
+	//bitpeek:pack:1
+	{`wide`, `'All:'X.64@`,
+		map[string]uint64{"All": 0xffffffffffffffff}, 0xffffffffffffffff, ``},
+	//bitpeek:pack:1
+	{`too big`, `'Type:'F`,
+		map[string]uint64{"Type": 8}, 0, `bitpeek: pic at 7: value does not fit Type`},
+	//bitpeek:pack:1
+	{`too small`, `'T:'Q00.0.04@`,
+		map[string]uint64{"T": 0xfffffffffffffff7}, 0, `bitpeek: pic at 4: value does not fit T`},
+	//bitpeek:pack:1
+	{`unknown`, `'Type:'F`,
+		map[string]uint64{"Typo": 1}, 0, `bitpeek: pic at 8: no field named Typo`},
+	//bitpeek:pack:1
+	{`no name`, `:D.16@`,
+		map[string]uint64{"D": 1}, 0, `bitpeek: pic at 6: no field named D`},
+	//bitpeek:pack:1
+	{`skip no name`, `'Skip:'!08@HH`,
+		map[string]uint64{"Skip": 1}, 0, `bitpeek: pic at 13: no field named Skip`},
+	//bitpeek:pack:1
+	{`switch`, `'T:'F[ ping:HH| raw:HH]`,
+		map[string]uint64{"T": 1}, 0, `bitpeek: pic at 5: Pack can not fill a switch block`},
+	//bitpeek:pack:1
+	{`bad pic`, `'T:'D.99@`,
+		nil, 0, `bitpeek: pic at 8: bad bitcount or unknown @ command`},
+}
+
+func TestPack(t *testing.T) {
+	for _, v := range packTests {
+		o, err := Pack(v.pic, v.fields)
+		e := ""
+		if err != nil {
+			e = err.Error()
+		}
+		if o != v.out || e != v.err {
+			t.Errorf("%s: o≢e >%#x %s< ≢ >%#x %s<", v.name, o, e, v.out, v.err)
+		}
+	}
+}
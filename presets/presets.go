@@ -0,0 +1,166 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+// Package presets gives ready made picstrings for common network headers:
+// Ethernet II, IPv4, TCP, UDP and ICMP. Headers are read from byte slices
+// as they come from the wire:
+//
+//	fmt.Printf("%s\n", presets.UDP.Snap(pkt[34:]))
+//
+//	Output:
+//	UDP sport:53 dport:41234 len:60 sum:0x1C2F
+//
+// Each Header also lists its Fields, so a single one can be read with Get,
+// eg. to pick the next header by the IPv4 proto field.
+package presets
+
+import "github.com/ohir/bitpeek"
+
+// Header is a protocol header of a fixed Len. Its Pic is for SnapBytes, so
+// it reads data cut into big-endian words of eight bytes with #d switches.
+type Header struct {
+	Name   string
+	Len    int     // bytes of header the Pic shows. Options are not shown.
+	Pic    string  // bitpeek.SnapBytes picstring
+	Fields []Field // fields in wire order
+}
+
+// Field is a bit range of a header. Bit 0 is the most significant bit of
+// the first header byte, as drawn in RFCs. Name is the one Pic labels it with.
+type Field struct {
+	Name  string
+	Bit   int // first bit
+	Width int // in bits, up to 64
+}
+
+// Snap shows data with h.Pic. Data past h.Len is not looked at, if there
+// is less data than h.Len the missing bytes read as zeros.
+func (h *Header) Snap(data []byte) []byte {
+	var b [80]byte
+	copy(b[:h.Len], data)
+	return bitpeek.SnapBytes(h.Pic, b[:h.Len])
+}
+
+// Get returns the value of a field called name and whether h has it. Bits
+// past the data end read as zeros.
+func (h *Header) Get(data []byte, name string) (uint64, bool) {
+	for _, f := range h.Fields {
+		if f.Name == name {
+			return bits(data, f.Bit, f.Width), true
+		}
+	}
+	return 0, false
+}
+
+// bits reads w bits starting at bit i of big-endian data.
+func bits(data []byte, i, w int) uint64 {
+	var v uint64
+	for ; w > 0; i, w = i+1, w-1 {
+		v <<= 1
+		if i>>3 < len(data) {
+			v |= uint64(data[i>>3]>>(7-i&7)) & 1
+		}
+	}
+	return v
+}
+
+// Ethernet is the Ethernet II frame header. The type is an EtherType,
+// 0x0800 for IPv4.
+var Ethernet = &Header{
+	Name: "Ethernet",
+	Len:  14,
+	Pic: `'ETH dst:'x008@:x008@:x008@:x008@:x008@:x008@` +
+		`' src:'x008@:x008@#1:x008@:x008@:x008@:x008@' type:0x'X016@`,
+	Fields: []Field{
+		{"dst", 0, 48},
+		{"src", 48, 48},
+		{"type", 96, 16},
+	},
+}
+
+// IPv4 is the IPv4 header with no options. Proto is 1 for ICMP, 6 for TCP
+// and 17 for UDP. Header with options is ihl*4 bytes long.
+var IPv4 = &Header{
+	Name: "IPv4",
+	Len:  20,
+	Pic: `'IPv4 ver:'D.04@' ihl:'D.04@' dscp:'D.06@' ecn:'E' len:'D.16@` +
+		`' id:0x'X016@!01@' DF> MF>' frag:'D.13@` +
+		`#1' ttl:'D.08@' proto:'D.08@' sum:0x'X016@' src:'IPv4.Address32@` +
+		`#2' dst:'IPv4.Address32@`,
+	Fields: []Field{
+		{"ver", 0, 4},
+		{"ihl", 4, 4},
+		{"dscp", 8, 6},
+		{"ecn", 14, 2},
+		{"len", 16, 16},
+		{"id", 32, 16},
+		{"DF", 49, 1},
+		{"MF", 50, 1},
+		{"frag", 51, 13},
+		{"ttl", 64, 8},
+		{"proto", 72, 8},
+		{"sum", 80, 16},
+		{"src", 96, 32},
+		{"dst", 128, 32},
+	},
+}
+
+// TCP is the TCP header with no options. Header with options is off*4
+// bytes long. Flags show uppercased if set.
+var TCP = &Header{
+	Name: "TCP",
+	Len:  20,
+	Pic: `'TCP sport:'D.16@' dport:'D.16@' seq:'D.......32@` +
+		`#1' ack:'D.......32@' off:'D.04@!04@` +
+		`' CWR= ECE= URG= ACK= PSH= RST= SYN= FIN=' win:'D.16@` +
+		`#2' sum:0x'X016@' urg:'D.16@`,
+	Fields: []Field{
+		{"sport", 0, 16},
+		{"dport", 16, 16},
+		{"seq", 32, 32},
+		{"ack", 64, 32},
+		{"off", 96, 4},
+		{"CWR", 104, 1},
+		{"ECE", 105, 1},
+		{"URG", 106, 1},
+		{"ACK", 107, 1},
+		{"PSH", 108, 1},
+		{"RST", 109, 1},
+		{"SYN", 110, 1},
+		{"FIN", 111, 1},
+		{"win", 112, 16},
+		{"sum", 128, 16},
+		{"urg", 144, 16},
+	},
+}
+
+// UDP is the UDP header.
+var UDP = &Header{
+	Name: "UDP",
+	Len:  8,
+	Pic:  `'UDP sport:'D.16@' dport:'D.16@' len:'D.16@' sum:0x'X016@`,
+	Fields: []Field{
+		{"sport", 0, 16},
+		{"dport", 16, 16},
+		{"len", 32, 16},
+		{"sum", 48, 16},
+	},
+}
+
+// ICMP is the ICMP header. Id and seq are those of echo request (type 8)
+// and reply (type 0), other types use these bytes their own way.
+var ICMP = &Header{
+	Name: "ICMP",
+	Len:  8,
+	Pic:  `'ICMP type:'D.08@' code:'D.08@' sum:0x'X016@' id:'D.16@' seq:'D.16@`,
+	Fields: []Field{
+		{"type", 0, 8},
+		{"code", 8, 8},
+		{"sum", 16, 16},
+		{"id", 32, 16},
+		{"seq", 48, 16},
+	},
+}
@@ -0,0 +1,107 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package presets
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohir/bitpeek"
+)
+
+// DNS answer: Ethernet, IPv4, UDP headers.
+var pkt = []byte{
+	0x00, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e, 0x52, 0x54, 0x00, 0x12, 0x34, 0x56, 0x08, 0x00,
+	0x45, 0x00, 0x00, 0x50, 0x1c, 0x46, 0x40, 0x00, 0x40, 0x11, 0xb1, 0xe6,
+	0xc0, 0xa8, 0x01, 0x01, 0xc0, 0xa8, 0x01, 0x64,
+	0x00, 0x35, 0xa1, 0x12, 0x00, 0x3c, 0x1c, 0x2f,
+}
+
+func ExampleHeader_Snap() {
+	fmt.Printf("%s\n", Ethernet.Snap(pkt))
+	fmt.Printf("%s\n", IPv4.Snap(pkt[14:]))
+	if proto, _ := IPv4.Get(pkt[14:], "proto"); proto == 17 {
+		fmt.Printf("%s\n", UDP.Snap(pkt[34:]))
+	}
+
+	// Output:
+	// ETH dst:00:1a:2b:3c:4d:5e src:52:54:00:12:34:56 type:0x0800
+	// IPv4 ver:4 ihl:5 dscp:0 ecn:0 len:80 id:0x1C46 DF frag:0 ttl:64 proto:17 sum:0xB1E6 src:192.168.1.1 dst:192.168.1.100
+	// UDP sport:53 dport:41234 len:60 sum:0x1C2F
+}
+
+var headerTests = []struct {
+	h    *Header
+	data []byte
+	out  string
+}{
+	{TCP, []byte{
+		0xc3, 0x50, 0x01, 0xbb, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00, 0x20, 0x01,
+		0x50, 0x12, 0xfa, 0xf0, 0xab, 0xcd, 0x00, 0x00, 0xff},
+		`TCP sport:50000 dport:443 seq:4096 ack:8193 off:5 cwr ece urg ACK psh rst SYN fin win:64240 sum:0xABCD urg:0`},
+	{ICMP, []byte{0x08, 0x00, 0xf7, 0xfe, 0x00, 0x01, 0x00, 0x02},
+		`ICMP type:8 code:0 sum:0xF7FE id:1 seq:2`},
+	{UDP, []byte{0x00, 0x35}, // short
+		`UDP sport:53 dport:0 len:0 sum:0x0000`},
+	{IPv4, []byte{0x45, 0x00, 0x00, 0x1c, 0x00, 0x01, 0x20, 0x00, 0x01, 0x01},
+		`IPv4 ver:4 ihl:5 dscp:0 ecn:0 len:28 id:0x0001 MF frag:0 ttl:1 proto:1 sum:0x0000 src:0.0.0.0 dst:0.0.0.0`},
+}
+
+func TestSnap(t *testing.T) {
+	for _, v := range headerTests {
+		if o := string(v.h.Snap(v.data)); o != v.out {
+			t.Errorf("%s is broken! o≢e\n>%s<\n>%s<", v.h.Name, o, v.out)
+		}
+	}
+}
+
+// TestFields sets bits of each field in turn and checks that Pic shows a
+// change of just that field.
+func TestFields(t *testing.T) {
+	for _, h := range []*Header{Ethernet, IPv4, TCP, UDP, ICMP} {
+		if err := bitpeek.Check(h.Pic); err != nil {
+			t.Errorf("%s: %v", h.Name, err)
+		}
+		zero := strings.Fields(string(h.Snap(nil)))
+		next := 0
+		for _, f := range h.Fields {
+			if f.Bit < next || f.Width < 1 || f.Width > 64 || f.Bit+f.Width > h.Len*8 {
+				t.Errorf("%s.%s: bad bits", h.Name, f.Name)
+			}
+			next = f.Bit + f.Width
+			data := make([]byte, h.Len)
+			for i := f.Bit; i < f.Bit+f.Width; i++ {
+				data[i>>3] |= 0x80 >> (i & 7)
+			}
+			if v, ok := h.Get(data, f.Name); !ok || v != 1<<f.Width-1 {
+				t.Errorf("%s.%s: Get gave %#x %v", h.Name, f.Name, v, ok)
+			}
+			var diff []string
+			for _, s := range strings.Fields(string(h.Snap(data))) {
+				if !has(zero, s) {
+					diff = append(diff, s)
+				}
+			}
+			if len(diff) != 1 || !strings.HasPrefix(strings.ToLower(diff[0]), strings.ToLower(f.Name)) {
+				t.Errorf("%s.%s: changed %q", h.Name, f.Name, diff)
+			}
+		}
+	}
+	if _, ok := UDP.Get(nil, "none"); ok {
+		t.Errorf("Get found no such field")
+	}
+}
+
+func has(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+import "github.com/ohir/bitpeek/internal/parse"
+
+// Func MaxLen returns the length of the longest output Snap can make of pic,
+// so buffers for a ring logger or a display line can be sized once. Output
+// of pics with decimals, times and escapes may well be longer than pic:
+//
+//    bitpeek.MaxLen(`'id:'D.08@' at 'Ts0.32@`) // 30, pic is 23
+//
+// Commands take their own bits, so MaxLen finds the longest output of each
+// one and adds them up. Labels and conditional blocks count shown, switch
+// blocks count their longest variant. Bits past b63 are zeros, commands
+// that take them count as such. Only a Float cut by b63 is sized with its
+// top value, what may be short of exact.
+func MaxLen(pic string) int {
+	n, _ := widest(pic, 0, 0)
+	return n
+}
+
+// selector notes the switch block b whose selector starts at bit nb.
+func (p *peek) selector(pic string, b *blk, nb uint) {
+	n := 0
+	for ; ; n++ {
+		if _, r := parse.Variant(pic[:b.k], b.j, uint64(n)); r < 0 {
+			break
+		}
+	}
+	p.sels = append(p.sels, pswitch{nb, n})
+}
+
+// widest returns the longest output of pic and the value that makes it.
+// Search starts from v and does not change bits set in fixed.
+func widest(pic string, v, fixed uint64) (int, uint64) {
+	p := &peek{size: true}
+	snap(pic, v, make([]byte, len(pic)), len(pic), p)
+	for i := len(p.spans) - 1; i >= 0; i-- { // left to right
+		s := p.spans[i]
+		if s.lo >= 64 {
+			continue
+		}
+		w := s.w
+		if s.lo+w > 64 { // bits up to b63
+			w = 64 - s.lo
+		}
+		m := ^uint64(0)
+		if w < 64 {
+			m = 1<<w - 1
+		}
+		if fixed&(m<<s.lo) != 0 {
+			continue
+		}
+		cs := top(pic, s, m)
+		for _, sw := range p.sels {
+			if sw.lo != s.lo {
+				continue
+			}
+			best, bv := -1, v
+			for c := uint64(0); c < uint64(sw.n) && c <= m; c++ {
+				cs = append(cs, c)
+			}
+			for _, c := range cs {
+				if l, x := widest(pic, v&^(m<<s.lo)|c<<s.lo, fixed|m<<s.lo); l > best {
+					best, bv = l, x
+				}
+			}
+			return best, bv
+		}
+		if len(cs) == 0 {
+			continue
+		}
+		if c := pic[s.pi]; c == '>' || c == '<' { // show what it hides
+			v = v&^(m<<s.lo) | cs[0]<<s.lo
+		}
+		best := Len(pic, v)
+		for _, c := range cs {
+			x := v&^(m<<s.lo) | c<<s.lo
+			if l := Len(pic, x); l > best {
+				best, v = l, x
+			}
+		}
+	}
+	return Len(pic, v), v
+}
+
+// top returns values up to m among which the command s shows longest.
+// It returns nil for commands that show the same for any value.
+func top(pic string, s span, m uint64) []uint64 {
+	switch pic[s.pi] {
+	case '>':
+		return []uint64{1, 0}
+	case '<':
+		return []uint64{0, 1}
+	case 'C', 'A': // control, DEL, C1
+		return []uint64{0, 127 & m, 128 & m}
+	case 'D', 'I', 'U':
+		return []uint64{m}
+	case 'X', 'x', 'O':
+		if pic[s.pi+1] != '0' {
+			return []uint64{m}
+		}
+	case 'Q': // most negative
+		return []uint64{m, 1 << (s.w - 1) & m}
+	case 'F':
+		if s.pi+1 < len(pic) && pic[s.pi+1] == 'l' {
+			return []uint64{floatTop(s.w, m)}
+		}
+	case 'T':
+		return stampTop(pic[s.pi+1], Epochs[pic[s.pi+2]-48], m)
+	case 'P':
+		return []uint64{periodTop(pic[s.pi+1], m)}
+	}
+	return nil
+}
+
+// floatTop returns the value of k bits, up to m, that Float shows longest.
+func floatTop(k uint, m uint64) uint64 {
+	x := uint64(0xec532d47) // -1.02118866e+27
+	if k == 16 {
+		x = 0x8690 // -0.00010014
+	}
+	if x > m {
+		return m
+	}
+	return x
+}
+
+// stampTop returns values up to m that Tue.dd@ shows longest: those of the
+// farthest years on both sides.
+func stampTop(u byte, e int64, m uint64) []uint64 {
+	per, _ := unit(u)
+	c := []uint64{0, m}
+	if q := uint64(1<<63-1) - uint64(e); q < m/per { // t wraps
+		c = append(c, q*per, (q+1)*per)
+	}
+	return c
+}
+
+// periodTop returns the value up to m that Pu.dd@ shows longest. Those are
+// of most hours with two digit minutes and seconds, and with all digits of
+// a fraction. Or, for m under a second, of the widest unit.
+func periodTop(u byte, m uint64) uint64 {
+	per, fd := unit(u)
+	best, bv := 0, uint64(0)
+	try := func(v uint64) {
+		var b [40]byte
+		if l := len(b) - period(b[:], len(b), v, u); l > best {
+			best, bv = l, v
+		}
+	}
+	try(0)
+	sc := uint64(pow10(9 - fd)) // v*sc are ns
+	for _, b := range []uint64{1e3, 1e6, 1e9} {
+		c := (b+sc-1)/sc - 1
+		if c > m {
+			c = m
+		}
+		if c >= per {
+			c = per - 1
+		}
+		try(c)
+		if c > 0 {
+			try(c - 1)
+		}
+	}
+	s := m / per
+	if s == 0 {
+		return bv
+	}
+	for h := s / 3600; ; h-- {
+		ts, t := s, m // last second and value of the hour
+		if s-h*3600 > 3599 {
+			ts = h*3600 + 3599
+			t = ts*per + per - 1
+		}
+		for x := h * 3600; x < ts; x++ {
+			try(x*per + per - 1)
+		}
+		try(t)
+		if t%per > 0 {
+			try(t - 1)
+		}
+		if h == 0 || h < s/3600 {
+			break
+		}
+	}
+	return bv
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func ExampleMaxLen() {
+	pic := `'id:'D.08@' at 'Ts0.32@`
+	fmt.Println(len(pic), MaxLen(pic), Len(pic, 0x2a00000000))
+
+	// Output:
+	// 23 30 29
+}
+
+var maxLenTests = []struct {
+	name string
+	pic  string
+	max  int
+}{
+	//bitpeek:size:1
+	{`chars`, `'PT:'F 'EXT=.ACK= Id:0xFHH`, 21},
+	//bitpeek:size:1
+	{`labels`, `Yes>No< ?`, 7},
+	//bitpeek:size:1
+	{`decimal`, `D.16@`, 5},
+	//bitpeek:size:1
+	{`hex`, `X.12@ X012@ x.04@`, 9},
+	//bitpeek:size:1
+	{`ip`, `IPv4.Address32@`, 15},
+	//bitpeek:size:1
+	{`fixed`, `Q04.2.12@`, 7},
+	//bitpeek:size:1
+	{`unsigned fixed`, `U04.2.12@`, 6},
+	//bitpeek:size:1
+	{`float16`, `Float16@`, 11},
+	//bitpeek:size:1
+	{`float32`, `Float32@`, 15},
+	//bitpeek:size:1
+	{`time`, `Ts0.32@`, 20},
+	//bitpeek:size:1
+	{`time wraps`, `Ts0.64@`, 29},
+	//bitpeek:size:1
+	{`period`, `Pn.64@`, 24},
+	//bitpeek:size:1
+	{`period seconds`, `Ps.32@`, 14},
+	//bitpeek:size:1
+	{`period micro`, `Pn.16@`, 9},
+	//bitpeek:size:1
+	{`char`, `C`, 1},
+	//bitpeek:size:1
+	{`block`, `x>[ Id:D.16@]`, 10},
+	//bitpeek:size:1
+	{`switch`, `'T:'F[ ping:HH| len:D.05@ ch:E!01@| raw:HH]`, 15},
+	//bitpeek:size:1
+	{`repeat`, `D.04@{4/2}`, 9},
+	//bitpeek:size:1
+	{`past b63`, `D.08@!60@`, 2},
+	//bitpeek:size:1
+	{`cut by b63`, `D.16@!56@`, 3},
+	//bitpeek:size:1
+	{`bad`, `D.99@`, 5},
+}
+
+func TestMaxLen(t *testing.T) {
+	for _, v := range maxLenTests {
+		if o := MaxLen(v.pic); o != v.max {
+			t.Errorf("%s is broken! o≢e %d ≢ %d", v.name, o, v.max)
+		}
+	}
+	defer func(e EscStyle) { Escape = e }(Escape)
+	Escape = EscHex
+	if o := MaxLen(`C`); o != 4 {
+		t.Errorf("EscHex C is broken! o≢e %d ≢ 4", o)
+	}
+}
+
+// TestMaxLenAll checks MaxLen of random pics against all their values.
+func TestMaxLenAll(t *testing.T) {
+	toks := []string{`B`, `E`, `F`, `?`, `ON>`, `off<`, `Up=`, ` `, `A`,
+		`D.04@`, `D.03@`, `X.05@`, `O.04@`, `Q02.1.04@`, `U01.1.03@`,
+		`Pm.04@`, `Ps.03@`, `>[ x:D.03@]`, `<[ yy]`,
+		`E[ a| bb:D.02@| ccc!02@]`, `B[|D.03@!01@]`, `B{3}`, `D.02@{3/2,}`}
+	r := rand.New(rand.NewSource(48))
+	for i := 0; i < 500; i++ {
+		pic := ""
+		for j := 1 + r.Intn(5); j > 0; j-- {
+			pic += toks[r.Intn(len(toks))]
+		}
+		p := &peek{chk: true}
+		snap(pic, 0, make([]byte, len(pic)), len(pic), p)
+		if p.err != nil || p.nb > 16 {
+			continue
+		}
+		e := 0
+		for v := uint64(0); v < 1<<p.nb; v++ {
+			if l := Len(pic, v); l > e {
+				e = l
+			}
+		}
+		if o := MaxLen(pic); o != e {
+			t.Errorf("MaxLen of %q is broken! o≢e %d ≢ %d", pic, o, e)
+		}
+	}
+}
+
+func TestLen(t *testing.T) {
+	for _, v := range parseTests {
+		if o, e := Len(v.pic, v.inp), len(Snap(v.pic, v.inp)); o != e {
+			t.Errorf("Len of %s is broken! o≢e %d ≢ %d", v.name, o, e)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+// Func Snap takes a string and an uint64 as input data. It returns byteslice
+// filled with printable characters as directed by pic (format) string. Pic
+// string represents b63 on its left and b0 on the right. Parser starts at b0
+// so shorter ints can be simply cast.
+//
+// Snap never panics, whatever the pic and value. Bad pics give wrong output
+// or PICERR, never a crash. FuzzSnap keeps it so.
+//
+// Notes
+//
+// ➊ Use !dd@ skips if you are interested only in bits on higher positions:
+//     fmt.Printf("%s\n", bitpeek.Snap( // 48 to skip on the left of @.
+//           `'Type:'F 'EXT=.ACK= Id:0xFHH!48@`, 0xafdfdeadbeef4d0e))
+//
+//     Output:
+//     Type:5 ext.ACK Id:0x7DF
+//
+// ➋ UTF8 and ascii control characters (eg. NL) are passed as-is so you
+// can make readable conditional "label-line":
+//    bitpeek.Snap(`'
+//    This line will show only if bit b1 is set>
+//    This line will show only if bit b0 is unset<`,
+//    header)
+//
+// ➌ It is possible to omit opening ' for a label at the start of
+// the pic string:
+//    pic := `' Label<` //
+//    pic :=  ` Label<` // same effect as above
+//
+// ➍ H commands are grouped so \HHHH is a pic for 16b number in spite
+// of escape (backslash) in front of first H. Use 'H'HHH if you really
+// need literal H glued to the front of hex digits.
+//
+// ➎ \n\t escapes are always interpreted - even in a quoted text. There is
+// no way to output literal `\n` or `\t`. Don't try.
+//
+// ➏ Annotate all your strings with bplinter tag in form of special comment
+// put ABOVE the line(s) with the pictring itself:
+//
+//   //bitpeek:tag:skip
+//
+// Optional ":tag" field is used to match with linter's -m option.
+// Picstring tags need not to be unique. Optional ":skip" number tells linter
+// to skip a few (up to 7) next strings.  It helps where the picstring in the
+// source is a part of a longer literal:
+//
+//  //bitpeek:sometag:1
+//  {`Example`, `Type:'F 'EXT=.ACK= Id:0xFHH from IPv4.Address32@:D.16@`},
+//
+//  // :1 skips string `Example`
+//
+// ➐ Block brackets are interpreted only if [ is glued to a > or < command
+// or to a selector, and it has its closing ] pair. Otherwise both are emitted
+// as-is. Closing ] ends a label so 'Label>[...]' is fine. Use \[ and \] for
+// literal brackets after commands. Blocks can nest up to four levels deep.
+// A | splits variants only in a switch block, use \| there for a literal one.
+// Selector takes its bits after the variant, so for a tagged union where
+// type is on the left of the payload write the pic as in:
+//    'T:'F[ ping:HH| data len:D.05@ ch:E!01@| ack=.nak=!06@| raw:HH]
+// Shorter variants pad with a !dd@ skip. Use Check to validate widths.
+//
+// ➑ Repeat {n} applies to a B E F H G A C or @ command glued to its left.
+// Separator S is optional and defaults to a space, /g alone separates with
+// spaces. Groups count from the right, same as bits do, so B{12/4} shows
+// nibbles. Flocked Hs repeat as a whole: HH{4} is the same as H{8}.
+//
+func Snap(pic string, from uint64) []byte {
+	if m, _ := cache.m.Load().(map[string]*prog); m != nil {
+		if g := cached(m, pic); g != nil {
+			return g.run(pic, from)
+		}
+	}
+	ot, oi := snap(pic, from, make([]byte, len(pic)), len(pic), nil)
+	return ot[oi:]
+}
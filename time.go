@@ -0,0 +1,175 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package bitpeek
+
+// Epochs are what the e digit of a Tue.dd@ command picks, in seconds from
+// the Unix epoch. Defaults are 0: Unix 1970-01-01, 1: NTP 1900-01-01,
+// 2: GPS 1980-01-06 (leap seconds are not counted), 3: 2000-01-01. Set
+// your own ones once, before use.
+var Epochs = [10]int64{0, -2208988800, 315964800, 946684800}
+
+// unit returns how many u units make a second and digits of a fraction.
+// It returns 0s for an unknown unit.
+func unit(u byte) (uint64, int) {
+	switch u {
+	case 's':
+		return 1, 0
+	case 'm':
+		return 1e3, 3
+	case 'u':
+		return 1e6, 6
+	case 'n':
+		return 1e9, 9
+	}
+	return 0, 0
+}
+
+// stamp writes v, a count of u units since epoch e, as RFC 3339 UTC time.
+// It fills ot leftwards from oi and returns new oi. Room of 48 is needed.
+func stamp(ot []byte, oi int, v uint64, u byte, e int64) int {
+	per, fd := unit(u)
+	t := int64(v/per) + e
+	days := t / 86400
+	if t%86400 < 0 {
+		days--
+	}
+	sod := t - days*86400
+	// civil from days, H. Hinnant's algorithm
+	z := days + 719468
+	era := z / 146097
+	if z < 0 && z%146097 != 0 {
+		era--
+	}
+	doe := z - era*146097
+	yoe := (doe - doe/1460 + doe/36524 - doe/146096) / 365
+	y := yoe + era*400
+	doy := doe - (365*yoe + yoe/4 - yoe/100)
+	mp := (5*doy + 2) / 153
+	d := doy - (153*mp+2)/5 + 1
+	m := mp + 3
+	if m > 12 {
+		m -= 12
+		y++
+	}
+	oi--
+	ot[oi] = 'Z'
+	if fd > 0 {
+		oi = ldec(ot, oi, int64(v%per), fd)
+		oi--
+		ot[oi] = '.'
+	}
+	oi = ldec(ot, oi, sod%60, 2)
+	oi--
+	ot[oi] = ':'
+	oi = ldec(ot, oi, sod/60%60, 2)
+	oi--
+	ot[oi] = ':'
+	oi = ldec(ot, oi, sod/3600, 2)
+	oi--
+	ot[oi] = 'T'
+	oi = ldec(ot, oi, d, 2)
+	oi--
+	ot[oi] = '-'
+	oi = ldec(ot, oi, m, 2)
+	oi--
+	ot[oi] = '-'
+	if y < 0 {
+		oi = ldec(ot, oi, -y, 4)
+		oi--
+		ot[oi] = '-'
+	} else {
+		oi = ldec(ot, oi, y, 4)
+	}
+	return oi
+}
+
+// period writes v u units as a duration, in the form of Go's time.Duration
+// String: 1h2m3.5s, 1.5ms. It fills ot leftwards from oi and returns new
+// oi. Room of 40 is needed.
+func period(ot []byte, oi int, v uint64, u byte) int {
+	per, fd := unit(u)
+	s, f := v/per, int64(v%per)
+	for ; fd < 9; fd++ { // nanoseconds
+		f *= 10
+	}
+	if s == 0 {
+		x := "ns"
+		switch {
+		case f == 0:
+			x = "s"
+		case f < 1e3:
+		case f < 1e6:
+			x, fd = "µs", 3
+		default:
+			x, fd = "ms", 6
+		}
+		oi = lput(ot, oi, []byte(x))
+		if fd < 9 {
+			oi = lfrac(ot, oi, f%pow10(fd), fd)
+			f /= pow10(fd)
+		}
+		return ldec(ot, oi, f, 1)
+	}
+	oi--
+	ot[oi] = 's'
+	oi = lfrac(ot, oi, f, 9)
+	oi = ldec(ot, oi, int64(s%60), 1)
+	if s < 60 {
+		return oi
+	}
+	oi--
+	ot[oi] = 'm'
+	oi = ldec(ot, oi, int64(s/60%60), 1)
+	if s < 3600 {
+		return oi
+	}
+	oi--
+	ot[oi] = 'h'
+	for h := s / 3600; ; h /= 10 { // h may not fit int64
+		oi--
+		ot[oi] = byte(48 + h%10)
+		if h < 10 {
+			break
+		}
+	}
+	return oi
+}
+
+func isUnit(u byte) bool {
+	return u == 's' || u == 'm' || u == 'u' || u == 'n'
+}
+
+func pow10(n int) int64 {
+	x := int64(1)
+	for ; n > 0; n-- {
+		x *= 10
+	}
+	return x
+}
+
+// ldec writes non-negative x as at least w digits on the left of oi.
+func ldec(ot []byte, oi int, x int64, w int) int {
+	for ; x > 0 || w > 0; w-- {
+		oi--
+		ot[oi] = byte(48 + x%10)
+		x /= 10
+	}
+	return oi
+}
+
+// lfrac writes x, a fraction of fd digits, after a dot and with trailing
+// zeros trimmed. Zero x writes nothing.
+func lfrac(ot []byte, oi int, x int64, fd int) int {
+	if x == 0 {
+		return oi
+	}
+	for ; x%10 == 0; x /= 10 {
+		fd--
+	}
+	oi = ldec(ot, oi, x, fd)
+	oi--
+	ot[oi] = '.'
+	return oi
+}
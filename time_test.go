@@ -0,0 +1,76 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !bitpeek_tiny
+
+package bitpeek
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleSnap_timestamps() {
+	// Record: 42 bits of Unix ms, then a 22 bit ms duration
+	var rec uint64 = 1700000000123<<22 | 3723500
+
+	fmt.Printf("%s\n", Snap(`'at 'Tm0.42@' took 'Pm.22@`, rec))
+
+	// Output:
+	// at 2023-11-14T22:13:20.123Z took 1h2m3.5s
+}
+
+func TestStamp(t *testing.T) {
+	layouts := map[byte]string{
+		's': "2006-01-02T15:04:05Z",
+		'm': "2006-01-02T15:04:05.000Z",
+		'u': "2006-01-02T15:04:05.000000Z",
+		'n': "2006-01-02T15:04:05.000000000Z",
+	}
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := 0; i < 4096; i++ { // xorshift
+		x ^= x << 13
+		x ^= x >> 7
+		x ^= x << 17
+		for u, l := range layouts {
+			per, _ := unit(u)
+			v := x >> 30 // up to some 500 years of seconds
+			if per > 1 {
+				v = v/per*per + x%per
+			}
+			for e, ep := range Epochs[:4] {
+				pic := fmt.Sprintf("T%c%d.64@", u, e)
+				want := time.Unix(int64(v/per)+ep, int64(v%per*(1e9/per))).UTC().Format(l)
+				if o := string(Snap(pic, v)); o != want {
+					t.Fatalf("%s of %d: got %s want %s", pic, v, o, want)
+				}
+			}
+		}
+	}
+}
+
+func TestPeriod(t *testing.T) {
+	vals := []uint64{0, 1, 9, 10, 999, 1000, 1001, 1500, 59999, 60000, 3599999,
+		3600000, 3723500, 86400000, 1<<40 - 1}
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := 0; i < 4096; i++ { // xorshift
+		x ^= x << 13
+		x ^= x >> 7
+		x ^= x << 17
+		vals = append(vals, x>>(x%64))
+	}
+	for _, v := range vals {
+		for _, u := range []byte("smun") {
+			per, _ := unit(u)
+			if v > 1<<63/(1e9/per) { // does not fit Duration
+				continue
+			}
+			want := (time.Duration(v) * time.Duration(1e9/per)).String()
+			if o := string(Snap(fmt.Sprintf("P%c.64@", u), v)); o != want {
+				t.Fatalf("P%c.64@ of %d: got %s want %s", u, v, o, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2018 OHIR-RIPE. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build bitpeek_tiny
+
+package bitpeek
+
+// With bitpeek_tiny tag only functions that make no garbage are built:
+// AppendSnap, Len, SnapRows and SnapLine. Check is kept for use at init.
+// Snap variants that peek at fields are not, so their hooks in snap do
+// nothing. Test it with: go test -tags bitpeek_tiny ./...
+
+func (p *peek) cut(ot []byte, oi, pi int, nb uint, w byte) ([]byte, int) {
+	return ot, oi
+}
+
+func (p *peek) selector(pic string, b *blk, nb uint) {}
+
+func (p *peek) open(b *blk) {}
+
+func (p *peek) redo(b *blk) {}
+
+func (p *peek) hide(b *blk, nb uint) {}
+
+func word(pic string, d int) int { return d }
+
+func (p *peek) to(w int, from uint64) uint64 { return from }